@@ -0,0 +1,91 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package clock provides an abstraction of time, so the timer-driven code can be
+// tested deterministically with the fake implemention.
+package clock
+
+import "time"
+
+// Clock is the interface to provide the current time and timer.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// After waits for the duration to elapse and then sends the current time
+	// on the returned channel.
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer creates a new Timer that will send the current time on its channel
+	// after at least duration d.
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is the representation of single event, like time.Timer.
+type Timer interface {
+	// C returns the channel on which the time is delivered
+	C() <-chan time.Time
+
+	// Stop prevents the Timer from firing. It returns true if the call stops the timer,
+	// false if the timer has already expired or been stopped.
+	Stop() bool
+}
+
+// New returns the Clock which is backed by the time package
+func New() Clock {
+	return realClock{}
+}
+
+// realClock implement the Clock interface with time package
+type realClock struct{}
+
+// Now implement Clock.Now
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// After implement Clock.After
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTimer implement Clock.NewTimer
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{
+		t: time.NewTimer(d),
+	}
+}
+
+// realTimer implement the Timer interface with time.Timer
+type realTimer struct {
+	t *time.Timer
+}
+
+// C implement Timer.C
+func (t *realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+// Stop implement Timer.Stop
+func (t *realTimer) Stop() bool {
+	return t.t.Stop()
+}
@@ -0,0 +1,68 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type clockTestSuite struct {
+	suite.Suite
+
+	c Clock
+}
+
+func (s *clockTestSuite) SetupTest() {
+	s.c = New()
+}
+
+func (s *clockTestSuite) TestNow() {
+	before := time.Now()
+	n := s.c.Now()
+	after := time.Now()
+	s.False(n.Before(before))
+	s.False(n.After(after))
+}
+
+func (s *clockTestSuite) TestAfter() {
+	before := time.Now()
+	n := <-s.c.After(time.Millisecond)
+	s.True(n.Sub(before) >= time.Millisecond)
+}
+
+func (s *clockTestSuite) TestNewTimer() {
+	t := s.c.NewTimer(time.Millisecond)
+	<-t.C()
+	s.False(t.Stop())
+
+	t = s.c.NewTimer(time.Hour)
+	s.True(t.Stop())
+}
+
+func TestClockTestSuite(t *testing.T) {
+	s := &clockTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,163 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is an Clock which time only changes by Advance, for test usage.
+type FakeClock interface {
+	Clock
+
+	// Advance moves the current time forward by d, and fires all the timers
+	// which expiration is reached.
+	Advance(d time.Duration)
+
+	// BlockUntil blocks until there is at least n pending timers, it can be used to
+	// wait the code under test has started waiting before Advance.
+	BlockUntil(n int)
+}
+
+// NewFake returns the FakeClock with the initial time t
+func NewFake(t time.Time) FakeClock {
+	c := &fakeClock{
+		now: t,
+	}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// fakeClock implement the FakeClock interface
+type fakeClock struct {
+	// protect the now and timers
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// now is the current time of the clock
+	now time.Time
+
+	// timers is the pending timers which haven't been fired or stopped
+	timers []*fakeTimer
+}
+
+// Now implement Clock.Now
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After implement Clock.After
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer implement Clock.NewTimer
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{
+		c:          c,
+		ch:         make(chan time.Time, 1),
+		expiration: c.now.Add(d),
+	}
+	if d <= 0 {
+		// expired already, fire immediately
+		t.ch <- c.now
+		return t
+	}
+
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Advance implement FakeClock.Advance
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, t := range c.timers {
+		if t.expiration.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+
+		t.ch <- c.now
+	}
+	for i := len(pending); i < len(c.timers); i++ {
+		// set element to nil for GC
+		c.timers[i] = nil
+	}
+	c.timers = pending
+}
+
+// BlockUntil implement FakeClock.BlockUntil
+func (c *fakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+// remove the timer from the pending timers, return true if the timer is pending
+func (c *fakeClock) remove(t *fakeTimer) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, v := range c.timers {
+		if v == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer implement the Timer interface with fakeClock
+type fakeTimer struct {
+	c  *fakeClock
+	ch chan time.Time
+
+	// expiration is the time when the timer should fire
+	expiration time.Time
+}
+
+// C implement Timer.C
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop implement Timer.Stop
+func (t *fakeTimer) Stop() bool {
+	return t.c.remove(t)
+}
@@ -0,0 +1,131 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type fakeClockTestSuite struct {
+	suite.Suite
+
+	start time.Time
+	c     FakeClock
+}
+
+func (s *fakeClockTestSuite) SetupTest() {
+	s.start = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.c = NewFake(s.start)
+}
+
+func (s *fakeClockTestSuite) TestAdvance() {
+	s.Equal(s.start, s.c.Now())
+
+	s.c.Advance(time.Second)
+	s.Equal(s.start.Add(time.Second), s.c.Now())
+}
+
+func (s *fakeClockTestSuite) TestAfterExpired() {
+	select {
+	case n := <-s.c.After(0):
+		s.Equal(s.start, n)
+	default:
+		s.FailNow("expect After(0) fired immediately")
+	}
+}
+
+func (s *fakeClockTestSuite) TestAfter() {
+	ch1 := s.c.After(time.Second)
+	ch2 := s.c.After(2 * time.Second)
+
+	s.c.Advance(999 * time.Millisecond)
+	select {
+	case <-ch1:
+		s.FailNow("expect ch1 not fired")
+	default:
+	}
+
+	s.c.Advance(time.Millisecond)
+	select {
+	case n := <-ch1:
+		s.Equal(s.start.Add(time.Second), n)
+	default:
+		s.FailNow("expect ch1 fired")
+	}
+	select {
+	case <-ch2:
+		s.FailNow("expect ch2 not fired")
+	default:
+	}
+
+	s.c.Advance(time.Hour)
+	select {
+	case n := <-ch2:
+		s.Equal(s.start.Add(time.Hour+time.Second), n)
+	default:
+		s.FailNow("expect ch2 fired")
+	}
+}
+
+func (s *fakeClockTestSuite) TestTimerStop() {
+	t := s.c.NewTimer(time.Second)
+	s.True(t.Stop())
+	s.False(t.Stop())
+
+	s.c.Advance(time.Second)
+	select {
+	case <-t.C():
+		s.FailNow("expect stopped timer not fired")
+	default:
+	}
+
+	t = s.c.NewTimer(time.Second)
+	s.c.Advance(time.Second)
+	s.False(t.Stop())
+}
+
+func (s *fakeClockTestSuite) TestBlockUntil() {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.c.BlockUntil(2)
+	}()
+
+	s.c.After(time.Second)
+	select {
+	case <-done:
+		s.FailNow("expect BlockUntil blocked")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.c.After(time.Second)
+	<-done
+}
+
+func TestFakeClockTestSuite(t *testing.T) {
+	s := &fakeClockTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,209 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package delayqueue
+
+import (
+	"context"
+
+	"github.com/lsytj0413/ena/clock"
+	"github.com/lsytj0413/ena/ds/queue/priorityqueue"
+)
+
+// OverflowPolicy is the policy when offer into the full bounded DelayQueue
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the offerer until there is space in the queue
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowReject rejects the new element with ErrQueueFull
+	OverflowReject
+
+	// OverflowEvictLatest evicts the latest-expiring element to make space for the new element,
+	// the new element will been rejected with ErrQueueFull if it's the latest-expiring one.
+	OverflowEvictLatest
+)
+
+// WithOverflowPolicy set the OverflowPolicy field of bounded DelayQueue, the default value is OverflowBlock
+func WithOverflowPolicy(p OverflowPolicy) Option {
+	return optionFunc(func(opt *option) {
+		opt.OverflowPolicy = p
+	})
+}
+
+// WithEvictHandler set the EvictHandler field of bounded DelayQueue, it's called with the
+// evicted element by OverflowEvictLatest policy, the default EvictHandler does nothing.
+func WithEvictHandler(h func(h Handle)) Option {
+	return optionFunc(func(opt *option) {
+		opt.EvictHandler = h
+	})
+}
+
+// NewBounded construct a DelayQueue with the max count of element, the Clock and OverflowPolicy
+// can been set by the Option.
+func NewBounded(capacity int, opts ...Option) (DelayQueue, error) {
+	options := option{
+		Clock:          clock.New(),
+		OverflowPolicy: OverflowBlock,
+	}
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+
+	if capacity <= 0 {
+		return nil, ErrInvalidCapacity
+	}
+	if options.Clock == nil {
+		return nil, ErrInvalidClock
+	}
+	switch options.OverflowPolicy {
+	case OverflowBlock, OverflowReject, OverflowEvictLatest:
+	default:
+		return nil, ErrInvalidOverflowPolicy
+	}
+
+	q := NewWithClock(capacity, options.Clock).(*delayQueue)
+	q.capacity = capacity
+	q.policy = options.OverflowPolicy
+	q.evict = options.EvictHandler
+	if q.policy == OverflowEvictLatest {
+		q.maxpq = priorityqueue.NewPriorityQueue(capacity)
+	}
+	return q, nil
+}
+
+// OfferContext implement the DelayQueue.OfferContext
+func (q *delayQueue) OfferContext(ctx context.Context, element interface{}, expireation int64) (Handle, error) {
+	for {
+		q.mu.Lock()
+		if q.capacity <= 0 || q.pq.Size()+q.reserved < q.capacity {
+			h := q.add(element, expireation)
+			index := h.e.Index()
+			q.mu.Unlock()
+
+			q.wakeup(index)
+			return h, nil
+		}
+
+		switch q.policy {
+		case OverflowReject:
+			q.mu.Unlock()
+			return nil, ErrQueueFull
+		case OverflowEvictLatest:
+			// all the space is reserved by the polled element when the maxpq is empty,
+			// there is nothing to evict
+			me := q.maxpq.Peek()
+			if me == nil {
+				q.mu.Unlock()
+				return nil, ErrQueueFull
+			}
+
+			latest := me.Value.(*handle)
+			if latest.e.Priority() <= expireation {
+				q.mu.Unlock()
+				return nil, ErrQueueFull
+			}
+
+			q.remove(latest)
+			latest.s = StateCanceled
+			q.canceled++
+			h := q.add(element, expireation)
+			index := h.e.Index()
+			q.mu.Unlock()
+
+			if q.evict != nil {
+				q.evict(latest)
+			}
+			q.wakeup(index)
+			return h, nil
+		}
+
+		// wait for the space
+		q.offerers++
+		spaceC := q.spaceC
+		q.mu.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-spaceC:
+		}
+
+		q.mu.Lock()
+		q.offerers--
+		q.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// add the new element into the queue, it must been called with the mu locked
+func (q *delayQueue) add(element interface{}, expireation int64) *handle {
+	h := &handle{
+		q: q,
+		v: element,
+		s: StatePending,
+	}
+	q.push(h, expireation)
+	return h
+}
+
+// push the handle into the priorityqueue, it must been called with the mu locked
+func (q *delayQueue) push(h *handle, expiration int64) {
+	h.e = q.pq.Add(h, expiration)
+	if q.maxpq != nil {
+		h.me = q.maxpq.Add(h, -expiration)
+	}
+	if h.e.Index() == 0 {
+		q.notify()
+	}
+}
+
+// remove the handle from the priorityqueue, it must been called with the mu locked
+func (q *delayQueue) remove(h *handle) {
+	_ = q.pq.Remove(h.e)
+	if q.maxpq != nil {
+		_ = q.maxpq.Remove(h.me)
+	}
+}
+
+// update the expiration of handle, it must been called with the mu locked
+func (q *delayQueue) update(h *handle, expiration int64) {
+	_ = q.pq.Update(h.e, expiration)
+	if q.maxpq != nil {
+		_ = q.maxpq.Update(h.me, -expiration)
+	}
+	if h.e.Index() == 0 {
+		q.notify()
+	}
+}
+
+// release wakes all the blocked offerers, it must been called with the mu locked
+func (q *delayQueue) release() {
+	if q.offerers > 0 {
+		close(q.spaceC)
+		q.spaceC = make(chan struct{})
+	}
+}
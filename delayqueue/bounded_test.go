@@ -0,0 +1,193 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package delayqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/lsytj0413/ena/clock"
+)
+
+type boundedTestSuite struct {
+	suite.Suite
+
+	c clock.FakeClock
+	n int64
+}
+
+func (s *boundedTestSuite) SetupTest() {
+	s.c = clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	s.n = clockTimer{c: s.c, unit: time.Millisecond}.Now()
+}
+
+func (s *boundedTestSuite) TestNewBoundedInvalid() {
+	_, err := NewBounded(0)
+	s.Equal(ErrInvalidCapacity, err)
+	_, err = NewBounded(1, WithClock(nil))
+	s.Equal(ErrInvalidClock, err)
+	_, err = NewBounded(1, WithOverflowPolicy(OverflowPolicy(-1)))
+	s.Equal(ErrInvalidOverflowPolicy, err)
+}
+
+func (s *boundedTestSuite) TestReject() {
+	dq, err := NewBounded(2, WithClock(s.c), WithOverflowPolicy(OverflowReject))
+	s.NoError(err)
+
+	dq.Offer(1, s.n+10)
+	h, err := dq.OfferContext(context.Background(), 2, s.n+20)
+	s.NoError(err)
+
+	_, err = dq.OfferContext(context.Background(), 3, s.n)
+	s.Equal(ErrQueueFull, err)
+	rejected := dq.Offer(3, s.n)
+	s.Equal(StateCanceled, rejected.State())
+	s.Equal(3, rejected.Value())
+	s.Equal(s.n, rejected.Expiration())
+	s.False(rejected.Cancel())
+	s.False(rejected.Reschedule(s.n))
+	s.Equal(2, dq.Size())
+
+	// there is space after canceled
+	s.True(h.Cancel())
+	_, err = dq.OfferContext(context.Background(), 3, s.n)
+	s.NoError(err)
+
+	// there is space after taken
+	v, ok := dq.TryTake()
+	s.True(ok)
+	s.Equal(3, v)
+	_, err = dq.OfferContext(context.Background(), 4, s.n+30)
+	s.NoError(err)
+
+	// there is space after drained
+	s.Len(dq.Drain(), 2)
+	_, err = dq.OfferContext(context.Background(), 5, s.n)
+	s.NoError(err)
+}
+
+func (s *boundedTestSuite) TestEvictLatest() {
+	var evicted []Handle
+	dq, err := NewBounded(2,
+		WithClock(s.c),
+		WithOverflowPolicy(OverflowEvictLatest),
+		WithEvictHandler(func(h Handle) {
+			evicted = append(evicted, h)
+		}),
+	)
+	s.NoError(err)
+
+	h1 := dq.Offer(1, s.n+10)
+	h2 := dq.Offer(2, s.n+20)
+
+	// the new element is the latest one
+	_, err = dq.OfferContext(context.Background(), 3, s.n+30)
+	s.Equal(ErrQueueFull, err)
+	s.Empty(evicted)
+
+	_, err = dq.OfferContext(context.Background(), 3, s.n+15)
+	s.NoError(err)
+	s.Equal([]Handle{h2}, evicted)
+	s.Equal(StateCanceled, h2.State())
+	s.Equal(1, dq.Len(StateCanceled))
+
+	// the latest element changed by reschedule
+	s.True(h1.Reschedule(s.n + 40))
+	h4 := dq.Offer(4, s.n+5)
+	s.Equal([]Handle{h2, h1}, evicted)
+	s.Equal(h4, dq.Peek())
+
+	s.c.Advance(20 * time.Millisecond)
+	vs, err := dq.TakeN(context.Background(), 0)
+	s.NoError(err)
+	s.Equal([]interface{}{4, 3}, vs)
+}
+
+func (s *boundedTestSuite) TestEvictLatestReserved() {
+	dq, err := NewBounded(1, WithClock(s.c), WithOverflowPolicy(OverflowEvictLatest))
+	s.NoError(err)
+	dq.Offer(1, s.n)
+
+	// the element is polled but not received, the space is reserved by it
+	pollCtx, pollCancel := context.WithCancel(context.Background())
+	defer pollCancel()
+	go dq.Poll(pollCtx)
+	q := dq.(*delayQueue)
+	s.Eventually(func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.reserved == 1
+	}, time.Second, time.Millisecond)
+
+	// there is nothing to evict
+	_, err = dq.OfferContext(context.Background(), 2, s.n)
+	s.Equal(ErrQueueFull, err)
+	s.Equal(StateCanceled, dq.Offer(2, s.n).State())
+
+	s.Equal(1, <-dq.Chan())
+	s.Eventually(func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.reserved == 0
+	}, time.Second, time.Millisecond)
+	_, err = dq.OfferContext(context.Background(), 2, s.n)
+	s.NoError(err)
+}
+
+func (s *boundedTestSuite) TestBlock() {
+	dq, err := NewBounded(1, WithClock(s.c))
+	s.NoError(err)
+	dq.Offer(1, s.n)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = dq.OfferContext(ctx, 2, s.n)
+	s.Equal(context.DeadlineExceeded, err)
+
+	done := make(chan Handle)
+	go func() {
+		done <- dq.Offer(2, s.n)
+	}()
+	select {
+	case <-done:
+		s.FailNow("expect the offer blocked")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	// the poll fired the element and release the space
+	pollCtx, pollCancel := context.WithCancel(context.Background())
+	go dq.Poll(pollCtx)
+	defer pollCancel()
+	s.Equal(1, <-dq.Chan())
+	h := <-done
+	s.Equal(2, h.Value())
+	s.Equal(2, <-dq.Chan())
+}
+
+func TestBoundedTestSuite(t *testing.T) {
+	s := &boundedTestSuite{}
+	suite.Run(t, s)
+}
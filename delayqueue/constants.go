@@ -0,0 +1,53 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package delayqueue
+
+import (
+	"fmt"
+)
+
+var (
+	// ErrQueueClosed is the error when the DurableQueue is closed
+	ErrQueueClosed = fmt.Errorf("queue is closed")
+
+	// ErrItemNotFound is the error when the item doesn't exist or has been acked
+	ErrItemNotFound = fmt.Errorf("item not found")
+
+	// ErrInvalidVisibilityTimeout is the error for invalid visibility timeout value
+	ErrInvalidVisibilityTimeout = fmt.Errorf("visibility timeout must greater than zero")
+
+	// ErrInvalidCompactThreshold is the error for invalid compact threshold value
+	ErrInvalidCompactThreshold = fmt.Errorf("compact threshold must greater than zero")
+
+	// ErrQueueFull is the error when the bounded queue is full
+	ErrQueueFull = fmt.Errorf("queue is full")
+
+	// ErrInvalidCapacity is the error for invalid capacity value
+	ErrInvalidCapacity = fmt.Errorf("capacity must greater than zero")
+
+	// ErrInvalidOverflowPolicy is the error for unknown OverflowPolicy value
+	ErrInvalidOverflowPolicy = fmt.Errorf("invalid overflow policy")
+
+	// ErrInvalidClock is the error for nil clock
+	ErrInvalidClock = fmt.Errorf("clock must not be nil")
+)
@@ -29,6 +29,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/lsytj0413/ena/clock"
 	"github.com/lsytj0413/ena/ds/queue/priorityqueue"
 )
 
@@ -41,8 +42,15 @@ type Element = priorityqueue.Element
 type DelayQueue interface {
 	// Offer insert the element into the current DelayQueue,
 	// if the expiration is blow the current min expiration, the item will
-	// been fired first.
-	Offer(elem interface{}, expireation int64)
+	// been fired first. The returned Handle can been used to cancel or reschedule
+	// the element. If the element is rejected by the bounded queue, the Handle
+	// is StateCanceled.
+	Offer(elem interface{}, expireation int64) Handle
+
+	// OfferContext insert the element like Offer, and return the error if the element is rejected.
+	// If the bounded queue is full, it will wait until there is space or the ctx is done with the
+	// OverflowBlock policy, or return ErrQueueFull with the OverflowReject policy.
+	OfferContext(ctx context.Context, elem interface{}, expireation int64) (Handle, error)
 
 	// Poll starts an infinite loop, it will continually waits for an element to
 	// been fired, and send the element to the output Chan.
@@ -54,6 +62,31 @@ type DelayQueue interface {
 
 	// Size return the element count in the queue
 	Size() int
+
+	// Peek return the element with the earliest expiration without remove it,
+	// it returns nil if the queue is empty.
+	Peek() Handle
+
+	// Drain removes all the pending elements from the queue, and return them by the
+	// order of expiration. The state of the elements will been StateCanceled.
+	Drain() []Handle
+
+	// Take removes and return the expired element with the earliest expiration, it will wait
+	// until an element expired or the ctx is done. It can been called by multiple consumers
+	// concurrently without Poll.
+	Take(ctx context.Context) (interface{}, error)
+
+	// TryTake removes and return the expired element with the earliest expiration, it returns
+	// false if there is no element expired.
+	TryTake() (interface{}, bool)
+
+	// TakeN waits like Take, and removes at most max expired elements by the order of expiration,
+	// all the expired elements will been removed if max isn't positive.
+	TakeN(ctx context.Context, max int) ([]interface{}, error)
+
+	// Len return the element count with the state, the StatePending count is equal to Size,
+	// the StateFired and StateCanceled count is accumulated since the queue created.
+	Len(s State) int
 }
 
 // delayQueue implement the DelayQueue interface
@@ -68,44 +101,114 @@ type delayQueue struct {
 	// T is the timer to provide the current ms
 	T Timer
 
+	// clock is used to wait for the fired point of element
+	clock clock.Clock
+
+	// unit is the time unit of the expiration and T.Now
+	unit time.Duration
+
 	// pq is the priorityqueue of expiration
 	pq priorityqueue.PriorityQueue
 
-	// protect the add/remove/update operation in the PriorityQueue
-	// TODO(yangsonglin): implement the goroutine-safe PriorityQueue
+	// protect the add/remove/update operation in the PriorityQueue and the state of handle
 	mu sync.Mutex
 
 	// sleeping is the sleeping state of delayqueue, if the queue is waiting for fired, the value will be 1
 	sleeping int32
+
+	// fired and canceled is the count of element with the state, protected by mu
+	fired    int
+	canceled int
+
+	// notifyC is closed to notify the waiting consumers of Take when the earliest element
+	// changed, and waiters is the count of waiting consumers, both protected by mu
+	notifyC chan struct{}
+	waiters int
+
+	// capacity is the max count of element in the queue, the queue is unbounded if it isn't positive
+	capacity int
+	policy   OverflowPolicy
+	evict    func(h Handle)
+
+	// maxpq is the priorityqueue of negative expiration, it's used to find the latest-expiring
+	// element with the OverflowEvictLatest policy
+	maxpq priorityqueue.PriorityQueue
+
+	// reserved is the count of element which is removed from pq but not fired by Poll
+	reserved int
+
+	// spaceC is closed to notify the blocked offerers when there is space, and offerers is the count
+	// of blocked offerers, both protected by mu
+	spaceC   chan struct{}
+	offerers int
 }
 
 // New construct a DelayQueue with the initial size
 func New(size int) DelayQueue {
-	return NewWithTimer(size, defaultTimer)
+	return NewWithClock(size, clock.New())
 }
 
-// NewWithTimer construct a DelayQueue with the initial size and Timer
+// NewWithTimer construct a DelayQueue with the initial size and Timer,
+// the Timer only provide the current ms, the waiting is backed by time package.
 func NewWithTimer(size int, t Timer) DelayQueue {
+	q := NewWithClock(size, clock.New()).(*delayQueue)
+	q.T = t
+	return q
+}
+
+// NewWithClock construct a DelayQueue with the initial size and Clock,
+// both the current ms and the waiting is provided by the Clock.
+func NewWithClock(size int, c clock.Clock) DelayQueue {
+	return NewWithUnit(size, c, time.Millisecond)
+}
+
+// NewWithUnit construct a DelayQueue with the initial size, Clock and the time unit of expiration,
+// such as time.Microsecond. If the unit isn't positive, time.Millisecond will been used.
+func NewWithUnit(size int, c clock.Clock, unit time.Duration) DelayQueue {
+	if unit <= 0 {
+		unit = time.Millisecond
+	}
+	if size < 0 {
+		size = 0
+	}
+
 	return &delayQueue{
 		C:       make(chan interface{}),
 		wakeupC: make(chan struct{}),
-		T:       t,
-		pq:      priorityqueue.NewPriorityQueue(1),
+		T:       clockTimer{c: c, unit: unit},
+		clock:   c,
+		unit:    unit,
+		pq:      priorityqueue.NewPriorityQueue(size),
+		notifyC: make(chan struct{}),
+		spaceC:  make(chan struct{}),
 	}
 }
 
 // TODO(yangsonglin): is't too difficult to deal with the sleeping, so change to the worker model?
 // Offer implement the DelayQueue.Offer
-func (q *delayQueue) Offer(element interface{}, expireation int64) {
-	_push := func() (*Element, int) {
-		q.mu.Lock()
-		defer q.mu.Unlock()
+func (q *delayQueue) Offer(element interface{}, expireation int64) Handle {
+	h, err := q.OfferContext(context.Background(), element, expireation)
+	if err != nil {
+		return &handle{
+			q:   q,
+			v:   element,
+			s:   StateCanceled,
+			exp: expireation,
+		}
+	}
+	return h
+}
 
-		e := q.pq.Add(element, expireation)
-		return e, e.Index()
+// notify wakes all the waiting consumers of Take, it must been called with the mu locked
+func (q *delayQueue) notify() {
+	if q.waiters > 0 {
+		close(q.notifyC)
+		q.notifyC = make(chan struct{})
 	}
-	_, index := _push()
+}
 
+// wakeup wakes the Poll loop if the element with index is the first element
+func (q *delayQueue) wakeup(index int) {
 	// there is no concurrent protection, EX:
 	// 1. goroutine1 add element with expireation 100
 	// 2. goroutine2 add element with expireation 50
@@ -155,6 +258,11 @@ func pollImpl(ctx context.Context, q *delayQueue) bool {
 		// No item left, change the sleeping state to 1
 		atomic.StoreInt32(&q.sleeping, 1)
 	}
+	// the priority maybe changed by Reschedule, so we read it in the lock
+	var expiration int64
+	if item != nil {
+		expiration = item.Priority()
+	}
 	q.mu.Unlock()
 
 	// we have got the min expiration item, it maybe nil for empty pq
@@ -169,27 +277,52 @@ func pollImpl(ctx context.Context, q *delayQueue) bool {
 	}
 
 	// have item, wait for the fired point
-	delta := item.Priority() - n
+	delta := expiration - n
 	if delta <= 0 {
-		// the item need fired, send the value to the output channel
+		// the item need fired, remove it before send to the output channel,
+		// so it can't been canceled or rescheduled after fired
+		h := item.Value.(*handle)
+		q.mu.Lock()
+		if q.pq.Peek() != item || item.Priority() != expiration {
+			// the item is canceled or rescheduled, go to next loop
+			q.mu.Unlock()
+			return true
+		}
+		q.remove(h)
+		q.reserved++
+		h.s = StateFired
+		q.mu.Unlock()
+
 		select {
 		// TODO(yangsonglin): change to executor
-		case q.C <- item.Value:
+		case q.C <- h.v:
 			// the element is fired
 			q.mu.Lock()
-			_ = q.pq.Remove(item)
+			q.fired++
+			q.reserved--
+			q.release()
 			q.mu.Unlock()
 			return true
 		case <-ctx.Done():
+			// the element isn't fired, put it back
+			q.mu.Lock()
+			q.push(h, expiration)
+			q.reserved--
+			h.s = StatePending
+			q.notify()
+			q.mu.Unlock()
 			return false
 		}
 	}
 
 	// the item is pending, wait for fired or new min element add
+	t := q.clock.NewTimer(time.Duration(delta) * q.unit)
+	defer t.Stop()
+
 	select {
 	case <-q.wakeupC:
 		return true
-	case <-time.After(time.Duration(delta) * time.Millisecond):
+	case <-t.C():
 		// we doesn't fired the item at there, go to next loop and the item will been fired because delta <= 0
 		if atomic.SwapInt32(&q.sleeping, 0) == 0 {
 			// if the old state is wakeup, the maybe an signal in wakeupC,
@@ -218,6 +351,142 @@ func (q *delayQueue) Size() int {
 	return q.pq.Size()
 }
 
+// Peek implement the DelayQueue.Peek
+func (q *delayQueue) Peek() Handle {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item := q.pq.Peek()
+	if item == nil {
+		return nil
+	}
+	return item.Value.(*handle)
+}
+
+// Drain implement the DelayQueue.Drain
+func (q *delayQueue) Drain() []Handle {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	hs := make([]Handle, 0, q.pq.Size())
+	for item := q.pq.Pop(); item != nil; item = q.pq.Pop() {
+		h := item.Value.(*handle)
+		h.s = StateCanceled
+		hs = append(hs, h)
+	}
+	if q.maxpq != nil {
+		q.maxpq = priorityqueue.NewPriorityQueue(0)
+	}
+	q.canceled += len(hs)
+	q.release()
+	return hs
+}
+
+// Take implement the DelayQueue.Take
+func (q *delayQueue) Take(ctx context.Context) (interface{}, error) {
+	vs, err := q.TakeN(ctx, 1)
+	if err != nil {
+		return nil, err
+	}
+	return vs[0], nil
+}
+
+// TryTake implement the DelayQueue.TryTake
+func (q *delayQueue) TryTake() (interface{}, bool) {
+	n := q.T.Now()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	vs := q.take(n, 1)
+	if len(vs) == 0 {
+		return nil, false
+	}
+	return vs[0], true
+}
+
+// TakeN implement the DelayQueue.TakeN
+func (q *delayQueue) TakeN(ctx context.Context, max int) ([]interface{}, error) {
+	for {
+		n := q.T.Now()
+
+		q.mu.Lock()
+		if vs := q.take(n, max); len(vs) > 0 {
+			q.mu.Unlock()
+			return vs, nil
+		}
+
+		// no element expired, wait for the earliest element or new element offered
+		delta := int64(-1)
+		if item := q.pq.Peek(); item != nil {
+			delta = item.Priority() - n
+		}
+		q.waiters++
+		notifyC := q.notifyC
+		q.mu.Unlock()
+
+		err := q.wait(ctx, notifyC, delta)
+
+		q.mu.Lock()
+		q.waiters--
+		q.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// take removes at most max expired elements at n, it must been called with the mu locked
+func (q *delayQueue) take(n int64, max int) []interface{} {
+	var vs []interface{}
+	for item := q.pq.Peek(); item != nil && item.Priority() <= n; item = q.pq.Peek() {
+		h := item.Value.(*handle)
+		q.remove(h)
+		q.release()
+		h.s = StateFired
+		q.fired++
+		vs = append(vs, h.v)
+		if max > 0 && len(vs) >= max {
+			break
+		}
+	}
+	return vs
+}
+
+// wait waits for the delta of unit, or notifyC closed, it waits without timeout if the delta is negative
+func (q *delayQueue) wait(ctx context.Context, notifyC <-chan struct{}, delta int64) error {
+	var timerC <-chan time.Time
+	if delta >= 0 {
+		t := q.clock.NewTimer(time.Duration(delta) * q.unit)
+		defer t.Stop()
+		timerC = t.C()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-notifyC:
+	case <-timerC:
+	}
+	return nil
+}
+
+// Len implement the DelayQueue.Len
+func (q *delayQueue) Len(s State) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	switch s {
+	case StatePending:
+		return q.pq.Size()
+	case StateFired:
+		return q.fired
+	case StateCanceled:
+		return q.canceled
+	}
+	return 0
+}
+
 func init() {
 	poll = pollImpl
 }
@@ -32,6 +32,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/suite"
+
+	"github.com/lsytj0413/ena/clock"
 )
 
 type delayQueueTestSuite struct {
@@ -310,6 +312,254 @@ func (s *delayQueueTestSuite) TestSize() {
 	s.Equal(0, s.dq.Size())
 }
 
+func (s *delayQueueTestSuite) TestNewWithClock() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	dq := NewWithClock(1, c)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		dq.Poll(ctx)
+	}()
+
+	n := clockTimer{c: c, unit: time.Millisecond}.Now()
+	dq.Offer(1, n+100)
+	dq.Offer(2, n+200)
+
+	// wait the poll sleeping for the first element
+	c.BlockUntil(1)
+	c.Advance(99 * time.Millisecond)
+	select {
+	case <-dq.Chan():
+		s.FailNow("expect element not fired")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Advance(time.Millisecond)
+	s.Equal(1, (<-dq.Chan()).(int))
+
+	c.BlockUntil(1)
+	c.Advance(100 * time.Millisecond)
+	s.Equal(2, (<-dq.Chan()).(int))
+	s.Equal(0, dq.Size())
+
+	cancel()
+	wg.Wait()
+}
+
+func (s *delayQueueTestSuite) TestNewWithUnit() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	dq := NewWithUnit(1, c, time.Microsecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		dq.Poll(ctx)
+	}()
+
+	n := clockTimer{c: c, unit: time.Microsecond}.Now()
+	s.Equal(c.Now().UnixNano()/int64(time.Microsecond), n)
+	dq.Offer(1, n+100)
+
+	// wait the poll sleeping for the first element
+	c.BlockUntil(1)
+	c.Advance(99 * time.Microsecond)
+	select {
+	case <-dq.Chan():
+		s.FailNow("expect element not fired")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	c.Advance(time.Microsecond)
+	s.Equal(1, (<-dq.Chan()).(int))
+
+	cancel()
+	wg.Wait()
+
+	s.Equal(time.Millisecond, NewWithUnit(1, c, 0).(*delayQueue).unit)
+}
+
+func (s *delayQueueTestSuite) TestPeek() {
+	s.Nil(s.dq.Peek())
+
+	n := defaultTimer.Now() + 1000
+	h := s.dq.Offer(1, n+10)
+	s.Equal(h, s.dq.Peek())
+
+	h = s.dq.Offer(2, n)
+	s.Equal(h, s.dq.Peek())
+	s.Equal(2, s.dq.Size())
+}
+
+func (s *delayQueueTestSuite) TestDrain() {
+	s.Empty(s.dq.Drain())
+
+	n := defaultTimer.Now() + 1000
+	h1 := s.dq.Offer(1, n+20)
+	h2 := s.dq.Offer(2, n)
+	h3 := s.dq.Offer(3, n+10)
+	s.True(h3.Cancel())
+
+	hs := s.dq.Drain()
+	s.Equal([]Handle{h2, h1}, hs)
+	for _, h := range hs {
+		s.Equal(StateCanceled, h.State())
+		s.False(h.Cancel())
+	}
+	s.Nil(s.dq.Peek())
+	s.Equal(0, s.dq.Len(StatePending))
+	s.Equal(0, s.dq.Len(StateFired))
+	s.Equal(3, s.dq.Len(StateCanceled))
+	s.Equal(0, s.dq.Len(State(-1)))
+}
+
+func (s *delayQueueTestSuite) TestPollImplFiredState() {
+	h := s.dq.Offer(1, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r := pollImpl(ctx, s.dq)
+	s.Equal(false, r)
+	s.Equal(StatePending, h.State())
+	s.Equal(h, s.dq.Peek())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.Equal(1, <-s.dq.Chan())
+	}()
+	r = pollImpl(context.Background(), s.dq)
+	s.Equal(true, r)
+	wg.Wait()
+	s.Equal(StateFired, h.State())
+	s.Equal(1, s.dq.Len(StateFired))
+}
+
+func (s *delayQueueTestSuite) TestTryTake() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	dq := NewWithClock(1, c)
+	n := clockTimer{c: c, unit: time.Millisecond}.Now()
+
+	_, ok := dq.TryTake()
+	s.False(ok)
+
+	h := dq.Offer(1, n+10)
+	dq.Offer(2, n)
+	v, ok := dq.TryTake()
+	s.True(ok)
+	s.Equal(2, v)
+	_, ok = dq.TryTake()
+	s.False(ok)
+
+	c.Advance(10 * time.Millisecond)
+	v, ok = dq.TryTake()
+	s.True(ok)
+	s.Equal(1, v)
+	s.Equal(StateFired, h.State())
+	s.Equal(2, dq.Len(StateFired))
+	s.Equal(0, dq.Size())
+}
+
+func (s *delayQueueTestSuite) TestTake() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	dq := NewWithClock(1, c)
+	n := clockTimer{c: c, unit: time.Millisecond}.Now()
+
+	type result struct {
+		v   interface{}
+		err error
+	}
+	ch := make(chan result, 2)
+	take := func(ctx context.Context) {
+		v, err := dq.Take(ctx)
+		ch <- result{v: v, err: err}
+	}
+
+	// wakeup by the element offered into the empty queue
+	go take(context.Background())
+	time.Sleep(10 * time.Millisecond)
+	dq.Offer(1, n)
+	r := <-ch
+	s.NoError(r.err)
+	s.Equal(1, r.v)
+
+	// wakeup by the element expired
+	dq.Offer(2, n+100)
+	go take(context.Background())
+	c.BlockUntil(1)
+	c.Advance(100 * time.Millisecond)
+	r = <-ch
+	s.NoError(r.err)
+	s.Equal(2, r.v)
+
+	// wakeup by the ctx
+	dq.Offer(3, n+200)
+	ctx, cancel := context.WithCancel(context.Background())
+	go take(ctx)
+	c.BlockUntil(1)
+	cancel()
+	r = <-ch
+	s.Equal(context.Canceled, r.err)
+	s.Nil(r.v)
+	s.Equal(1, dq.Size())
+}
+
+func (s *delayQueueTestSuite) TestTakeConcurrent() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	dq := NewWithClock(1, c)
+	n := clockTimer{c: c, unit: time.Millisecond}.Now()
+
+	dq.Offer(1, n+100)
+	ch := make(chan interface{}, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			v, err := dq.Take(context.Background())
+			s.NoError(err)
+			ch <- v
+		}()
+	}
+
+	c.BlockUntil(2)
+	// the earlier element will wakeup all the consumers
+	dq.Offer(2, n+50)
+	c.BlockUntil(2)
+	c.Advance(100 * time.Millisecond)
+
+	vs := []int{(<-ch).(int), (<-ch).(int)}
+	sort.Ints(vs)
+	s.Equal([]int{1, 2}, vs)
+}
+
+func (s *delayQueueTestSuite) TestTakeN() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	dq := NewWithClock(1, c)
+	n := clockTimer{c: c, unit: time.Millisecond}.Now()
+
+	for i := 0; i < 4; i++ {
+		dq.Offer(i, n+int64(i))
+	}
+	dq.Offer(4, n+100)
+	c.Advance(3 * time.Millisecond)
+
+	vs, err := dq.TakeN(context.Background(), 2)
+	s.NoError(err)
+	s.Equal([]interface{}{0, 1}, vs)
+
+	vs, err = dq.TakeN(context.Background(), 0)
+	s.NoError(err)
+	s.Equal([]interface{}{2, 3}, vs)
+	s.Equal(1, dq.Size())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	vs, err = dq.TakeN(ctx, 0)
+	s.Equal(context.DeadlineExceeded, err)
+	s.Nil(vs)
+}
+
 func TestDelayQueueTestSuite(t *testing.T) {
 	rand.Seed(time.Now().UnixNano())
 	s := &delayQueueTestSuite{}
@@ -0,0 +1,692 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package delayqueue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lsytj0413/ena/clock"
+	"github.com/lsytj0413/ena/internal/wal"
+)
+
+// Item is the element delivered by the DurableQueue, the consumer should Ack it by ID
+// after processed, otherwise it will been delivered again after the visibility timeout.
+type Item struct {
+	// ID is the unique identify of item in the queue
+	ID uint64 `json:"id"`
+
+	// Payload is the user data of item
+	Payload []byte `json:"payload,omitempty"`
+
+	// Expiration is the time when the item should been delivered
+	Expiration int64 `json:"expiration"`
+
+	// Attempts is the count of delivery, include the current one
+	Attempts int `json:"attempts"`
+}
+
+// Volatile is the element which is kept in memory by the DurableQueue rather than been persisted,
+// it's delivered by itself at-most-once and lost after crash. EX: the bucket of timingwheel.
+type Volatile interface {
+	Volatile()
+}
+
+// DurableQueue is the DelayQueue which persist the elements into the local disk, and delivers
+// the element at-least-once. The element been taken by Poll/Take/TryTake/TakeN is the Item,
+// except the Volatile element which is delivered by itself.
+// NOTE: the Volatile element is never persisted, so the DurableQueue persists nothing when used
+// as the DelayQueue of timingwheel, all the elements of it are Volatile buckets.
+type DurableQueue interface {
+	DelayQueue
+
+	// OfferPayload insert the payload into the queue like Offer, and return the error of persistence.
+	OfferPayload(payload []byte, expiration int64) (Handle, error)
+
+	// Ack removes the delivered item from the queue, it will not been delivered again.
+	Ack(id uint64) error
+
+	// Close closes the log file, the queue can't been modified after closed.
+	Close() error
+}
+
+// option is the options of DurableQueue and bounded DelayQueue
+type option struct {
+	// Clock is used to provide the current time and waiting, default is the clock.New()
+	Clock clock.Clock
+
+	// VisibilityTimeout is the duration of the delivered item been invisible, the item will
+	// been delivered again if it's not acked after the duration.
+	VisibilityTimeout time.Duration
+
+	// CompactThreshold is the count of log entry to trigger the compaction, the log will been
+	// compacted when the count of entry exceed the threshold and twice of the item count.
+	CompactThreshold int
+
+	// ErrorHandler is called when the Offer or delivery failed to persist
+	ErrorHandler func(err error)
+
+	// OverflowPolicy is the policy when the bounded queue is full
+	OverflowPolicy OverflowPolicy
+
+	// EvictHandler is called with the element evicted by the bounded queue
+	EvictHandler func(h Handle)
+}
+
+// Option is the interface to set option
+type Option interface {
+	Apply(*option)
+}
+
+// optionFunc wraps a func so it satisfies the Option interface
+type optionFunc func(*option)
+
+func (f optionFunc) Apply(opt *option) {
+	f(opt)
+}
+
+// WithClock set the Clock field
+func WithClock(c clock.Clock) Option {
+	return optionFunc(func(opt *option) {
+		opt.Clock = c
+	})
+}
+
+// WithVisibilityTimeout set the VisibilityTimeout field, the default value is 30s
+func WithVisibilityTimeout(d time.Duration) Option {
+	return optionFunc(func(opt *option) {
+		opt.VisibilityTimeout = d
+	})
+}
+
+// WithCompactThreshold set the CompactThreshold field, the default value is 1024
+func WithCompactThreshold(n int) Option {
+	return optionFunc(func(opt *option) {
+		opt.CompactThreshold = n
+	})
+}
+
+// WithErrorHandler set the ErrorHandler field, the default ErrorHandler does nothing
+func WithErrorHandler(h func(err error)) Option {
+	return optionFunc(func(opt *option) {
+		opt.ErrorHandler = h
+	})
+}
+
+// walOp is the operation type of durableQueue log entry
+type walOp = string
+
+const (
+	walOpOffer  walOp = "offer"
+	walOpRemove walOp = "remove"
+)
+
+// walEntry is the single line in the durableQueue log
+type walEntry struct {
+	Op walOp `json:"op"`
+	Item
+}
+
+// durableItem is the element of durableQueue, it implement the Handle interface,
+// all the fields is protected by the mu of durableQueue.
+type durableItem struct {
+	d    *durableQueue
+	item Item
+
+	// h is the handle of inner delayQueue, it's changed when the item is delivered
+	h Handle
+	s State
+}
+
+// Value implement the Handle.Value, it return the Item
+func (it *durableItem) Value() interface{} {
+	it.d.mu.Lock()
+	defer it.d.mu.Unlock()
+
+	return it.item
+}
+
+// Expiration implement the Handle.Expiration
+func (it *durableItem) Expiration() int64 {
+	it.d.mu.Lock()
+	defer it.d.mu.Unlock()
+
+	return it.item.Expiration
+}
+
+// State implement the Handle.State, the delivered item is StateFired whether acked or not
+func (it *durableItem) State() State {
+	it.d.mu.Lock()
+	defer it.d.mu.Unlock()
+
+	return it.s
+}
+
+// Cancel implement the Handle.Cancel
+func (it *durableItem) Cancel() bool {
+	it.d.mu.Lock()
+	defer it.d.mu.Unlock()
+
+	if it.s != StatePending || !it.h.Cancel() {
+		return false
+	}
+
+	it.d.remove(it)
+	return true
+}
+
+// Reschedule implement the Handle.Reschedule
+func (it *durableItem) Reschedule(expiration int64) bool {
+	it.d.mu.Lock()
+	defer it.d.mu.Unlock()
+
+	if it.s != StatePending || !it.h.Reschedule(expiration) {
+		return false
+	}
+
+	it.item.Expiration = expiration
+	it.d.report(it.d.append(walEntry{Op: walOpOffer, Item: it.item}))
+	return true
+}
+
+// volatileHandle is the Handle of Volatile element, it's backed by the handle of inner delayQueue
+type volatileHandle struct {
+	Handle
+	d *durableQueue
+}
+
+// Cancel implement the Handle.Cancel
+func (h *volatileHandle) Cancel() bool {
+	h.d.mu.Lock()
+	defer h.d.mu.Unlock()
+
+	if !h.Handle.Cancel() {
+		return false
+	}
+
+	h.d.volatile--
+	h.d.pending--
+	h.d.canceled++
+	return true
+}
+
+// durableQueue implement the DurableQueue interface, it's backed by the in-memory delayQueue
+// and an append-only wal.Log, every modification append one json line into the file and sync it.
+type durableQueue struct {
+	// C is the output channel of Poll
+	C chan interface{}
+
+	q   *delayQueue
+	opt option
+
+	// protect the fields below
+	mu sync.Mutex
+
+	// log is nil after closed
+	log *wal.Log
+
+	// items is the items which doesn't been acked
+	items  map[uint64]*durableItem
+	nextID uint64
+
+	// pending, fired and canceled is the count of element with the state, include
+	// the Volatile element
+	pending  int
+	fired    int
+	canceled int
+
+	// volatile is the count of pending Volatile element
+	volatile int
+}
+
+// NewDurable construct a DurableQueue with the log file at path, the file will been created if it
+// doesn't exist. The exists log is replayed when opened, and the items which been delivered but
+// not acked will been delivered again at the visibility deadline.
+func NewDurable(path string, opts ...Option) (DurableQueue, error) {
+	options := option{
+		Clock:             clock.New(),
+		VisibilityTimeout: 30 * time.Second,
+		CompactThreshold:  1024,
+	}
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+
+	if options.Clock == nil {
+		return nil, ErrInvalidClock
+	}
+	if options.VisibilityTimeout <= 0 {
+		return nil, ErrInvalidVisibilityTimeout
+	}
+	if options.CompactThreshold <= 0 {
+		return nil, ErrInvalidCompactThreshold
+	}
+
+	d := &durableQueue{
+		C:      make(chan interface{}),
+		q:      NewWithClock(1, options.Clock).(*delayQueue),
+		opt:    options,
+		items:  make(map[uint64]*durableItem),
+		nextID: 1,
+	}
+
+	items := make(map[uint64]Item)
+	log, err := wal.Open(path, func(data []byte) error {
+		var e walEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+
+		switch e.Op {
+		case walOpOffer:
+			items[e.ID] = e.Item
+		case walOpRemove:
+			delete(items, e.ID)
+		default:
+			return fmt.Errorf("InvalidLogOp: Op[%s]", e.Op)
+		}
+		return nil
+	}, func() []interface{} {
+		entries := make([]interface{}, 0, len(items))
+		for _, item := range items {
+			entries = append(entries, walEntry{Op: walOpOffer, Item: item})
+		}
+		return entries
+	})
+	if err != nil {
+		return nil, fmt.Errorf("DurableQueue: %w", err)
+	}
+	d.log = log
+
+	for _, item := range items {
+		it := &durableItem{
+			d:    d,
+			item: item,
+			s:    StatePending,
+		}
+		if item.Attempts > 0 {
+			// the item has been delivered before crash, wait for the visibility deadline
+			it.s = StateFired
+		} else {
+			d.pending++
+		}
+		it.h = d.q.Offer(it, item.Expiration)
+		d.items[item.ID] = it
+		if item.ID >= d.nextID {
+			d.nextID = item.ID + 1
+		}
+	}
+	return d, nil
+}
+
+// append the entry into the log file and sync it, the log will been compacted if
+// the count of entry exceed the threshold. It must been called with the mu locked.
+func (d *durableQueue) append(e walEntry) error {
+	if d.log == nil {
+		return ErrQueueClosed
+	}
+
+	if err := d.log.Append(e); err != nil {
+		return err
+	}
+	if !d.log.Compactable(len(d.items), d.opt.CompactThreshold) {
+		return nil
+	}
+
+	entries := make([]interface{}, 0, len(d.items)+1)
+	for id, it := range d.items {
+		if id != e.ID {
+			entries = append(entries, walEntry{Op: walOpOffer, Item: it.item})
+		}
+	}
+	if e.Op == walOpOffer {
+		// the entry maybe the new item which isn't in the items now
+		entries = append(entries, e)
+	}
+	return d.log.Compact(entries)
+}
+
+// report the error to the ErrorHandler
+func (d *durableQueue) report(err error) {
+	if err != nil && d.opt.ErrorHandler != nil {
+		d.opt.ErrorHandler(err)
+	}
+}
+
+// remove the canceled item, it must been called with the mu locked
+func (d *durableQueue) remove(it *durableItem) {
+	delete(d.items, it.item.ID)
+	if it.s == StatePending {
+		d.pending--
+		d.canceled++
+		it.s = StateCanceled
+	}
+	d.report(d.append(walEntry{Op: walOpRemove, Item: Item{ID: it.item.ID}}))
+}
+
+// deliver the item fired by the inner delayQueue, the item will been offered again with the
+// visibility deadline. It returns false if the item has been acked or canceled.
+func (d *durableQueue) deliver(v interface{}) (interface{}, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	it, ok := v.(*durableItem)
+	if !ok {
+		// the Volatile element is delivered only once
+		d.volatile--
+		d.pending--
+		d.fired++
+		return v, true
+	}
+
+	if d.items[it.item.ID] != it {
+		return nil, false
+	}
+
+	it.item.Attempts++
+	item := it.item
+	if it.s == StatePending {
+		d.pending--
+		d.fired++
+		it.s = StateFired
+	}
+
+	it.item.Expiration = d.q.T.Now() + int64(d.opt.VisibilityTimeout/d.q.unit)
+	d.report(d.append(walEntry{Op: walOpOffer, Item: it.item}))
+	it.h = d.q.Offer(it, it.item.Expiration)
+	return item, true
+}
+
+// Offer implement the DelayQueue.Offer, the element must be []byte, string, Volatile or can
+// been marshaled by json. If the element can't been persisted, the error is passed to the
+// ErrorHandler and the returned Handle is StateCanceled.
+func (d *durableQueue) Offer(elem interface{}, expiration int64) Handle {
+	h, err := d.OfferContext(context.Background(), elem, expiration)
+	if err != nil {
+		d.report(err)
+		return &durableItem{
+			d: d,
+			item: Item{
+				Expiration: expiration,
+			},
+			s: StateCanceled,
+		}
+	}
+	return h
+}
+
+// OfferContext implement the DelayQueue.OfferContext, the DurableQueue is unbounded so the
+// ctx is unused.
+func (d *durableQueue) OfferContext(ctx context.Context, elem interface{}, expiration int64) (Handle, error) {
+	var payload []byte
+	switch v := elem.(type) {
+	case Volatile:
+		return d.offerVolatile(v, expiration)
+	case []byte:
+		payload = v
+	case string:
+		payload = []byte(v)
+	default:
+		var err error
+		if payload, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.OfferPayload(payload, expiration)
+}
+
+// offerVolatile insert the Volatile element into the inner delayQueue without persistence
+func (d *durableQueue) offerVolatile(v Volatile, expiration int64) (Handle, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.log == nil {
+		return nil, ErrQueueClosed
+	}
+
+	d.volatile++
+	d.pending++
+	return &volatileHandle{
+		Handle: d.q.Offer(v, expiration),
+		d:      d,
+	}, nil
+}
+
+// OfferPayload implement the DurableQueue.OfferPayload
+func (d *durableQueue) OfferPayload(payload []byte, expiration int64) (Handle, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	it := &durableItem{
+		d: d,
+		item: Item{
+			ID:         d.nextID,
+			Payload:    payload,
+			Expiration: expiration,
+		},
+		s: StatePending,
+	}
+	if err := d.append(walEntry{Op: walOpOffer, Item: it.item}); err != nil {
+		return nil, err
+	}
+
+	d.nextID++
+	d.pending++
+	d.items[it.item.ID] = it
+	it.h = d.q.Offer(it, expiration)
+	return it, nil
+}
+
+// Ack implement the DurableQueue.Ack, the pending item will been canceled if acked
+func (d *durableQueue) Ack(id uint64) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.log == nil {
+		return ErrQueueClosed
+	}
+
+	it, ok := d.items[id]
+	if !ok {
+		return ErrItemNotFound
+	}
+
+	it.h.Cancel()
+	delete(d.items, id)
+	if it.s == StatePending {
+		d.pending--
+		d.canceled++
+		it.s = StateCanceled
+	}
+	return d.append(walEntry{Op: walOpRemove, Item: Item{ID: id}})
+}
+
+// Poll implement the DelayQueue.Poll
+func (d *durableQueue) Poll(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.q.Poll(ctx)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v := <-d.q.C:
+			item, ok := d.deliver(v)
+			if !ok {
+				continue
+			}
+
+			select {
+			case d.C <- item:
+			case <-ctx.Done():
+				// the item will been delivered again after the visibility timeout
+				return
+			}
+		}
+	}
+}
+
+// Chan implement the DelayQueue.Chan
+func (d *durableQueue) Chan() <-chan interface{} {
+	return d.C
+}
+
+// Size implement the DelayQueue.Size, it's the count of items which doesn't been acked
+// and the pending Volatile element
+func (d *durableQueue) Size() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return len(d.items) + d.volatile
+}
+
+// Peek implement the DelayQueue.Peek
+func (d *durableQueue) Peek() Handle {
+	h := d.q.Peek()
+	if h == nil {
+		return nil
+	}
+	if it, ok := h.Value().(*durableItem); ok {
+		return it
+	}
+	return &volatileHandle{
+		Handle: h,
+		d:      d,
+	}
+}
+
+// Drain implement the DelayQueue.Drain, all the items which doesn't been acked will been removed
+func (d *durableQueue) Drain() []Handle {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	hs := d.q.Drain()
+	for i, h := range hs {
+		it, ok := h.Value().(*durableItem)
+		if !ok {
+			d.volatile--
+			d.pending--
+			d.canceled++
+			hs[i] = &volatileHandle{
+				Handle: h,
+				d:      d,
+			}
+			continue
+		}
+
+		d.remove(it)
+		it.s = StateCanceled
+		hs[i] = it
+	}
+	return hs
+}
+
+// Len implement the DelayQueue.Len, the StateFired count is the count of item been delivered at first time
+func (d *durableQueue) Len(s State) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch s {
+	case StatePending:
+		return d.pending
+	case StateFired:
+		return d.fired
+	case StateCanceled:
+		return d.canceled
+	}
+	return 0
+}
+
+// Take implement the DelayQueue.Take
+func (d *durableQueue) Take(ctx context.Context) (interface{}, error) {
+	for {
+		v, err := d.q.Take(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if item, ok := d.deliver(v); ok {
+			return item, nil
+		}
+	}
+}
+
+// TryTake implement the DelayQueue.TryTake
+func (d *durableQueue) TryTake() (interface{}, bool) {
+	for {
+		v, ok := d.q.TryTake()
+		if !ok {
+			return nil, false
+		}
+		if item, ok := d.deliver(v); ok {
+			return item, true
+		}
+	}
+}
+
+// TakeN implement the DelayQueue.TakeN
+func (d *durableQueue) TakeN(ctx context.Context, max int) ([]interface{}, error) {
+	for {
+		vs, err := d.q.TakeN(ctx, max)
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]interface{}, 0, len(vs))
+		for _, v := range vs {
+			if item, ok := d.deliver(v); ok {
+				items = append(items, item)
+			}
+		}
+		if len(items) > 0 {
+			return items, nil
+		}
+	}
+}
+
+// Close implement the DurableQueue.Close
+func (d *durableQueue) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.log == nil {
+		return ErrQueueClosed
+	}
+
+	err := d.log.Close()
+	d.log = nil
+	return err
+}
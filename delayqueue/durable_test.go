@@ -0,0 +1,320 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package delayqueue
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/lsytj0413/ena/clock"
+)
+
+type durableQueueTestSuite struct {
+	suite.Suite
+
+	path string
+	c    clock.FakeClock
+	n    int64
+}
+
+func (s *durableQueueTestSuite) SetupTest() {
+	poll = pollImpl
+	s.path = filepath.Join(s.T().TempDir(), "queue.log")
+	s.c = clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	s.n = clockTimer{c: s.c, unit: time.Millisecond}.Now()
+}
+
+func (s *durableQueueTestSuite) open(opts ...Option) DurableQueue {
+	opts = append([]Option{WithClock(s.c), WithVisibilityTimeout(time.Second)}, opts...)
+	d, err := NewDurable(s.path, opts...)
+	s.NoError(err)
+	return d
+}
+
+func (s *durableQueueTestSuite) lines() int {
+	data, err := os.ReadFile(s.path)
+	s.NoError(err)
+	return bytes.Count(data, []byte("\n"))
+}
+
+func (s *durableQueueTestSuite) TestNewDurableInvalid() {
+	_, err := NewDurable(s.path, WithClock(nil))
+	s.Equal(ErrInvalidClock, err)
+	_, err = NewDurable(s.path, WithVisibilityTimeout(0))
+	s.Equal(ErrInvalidVisibilityTimeout, err)
+	_, err = NewDurable(s.path, WithCompactThreshold(0))
+	s.Equal(ErrInvalidCompactThreshold, err)
+
+	s.NoError(os.WriteFile(s.path, []byte("invalid\n{}\n"), 0644))
+	_, err = NewDurable(s.path)
+	s.Error(err)
+}
+
+func (s *durableQueueTestSuite) TestAck() {
+	d := s.open()
+	defer d.Close()
+
+	h, err := d.OfferPayload([]byte("a"), s.n+100)
+	s.NoError(err)
+	s.Equal(1, d.Len(StatePending))
+
+	_, ok := d.TryTake()
+	s.False(ok)
+
+	s.c.Advance(100 * time.Millisecond)
+	v, ok := d.TryTake()
+	s.True(ok)
+	item := v.(Item)
+	s.Equal(Item{ID: 1, Payload: []byte("a"), Expiration: s.n + 100, Attempts: 1}, item)
+	s.Equal(StateFired, h.State())
+	s.False(h.Cancel())
+	s.False(h.Reschedule(s.n))
+
+	// the item isn't acked, delivered again after the visibility timeout
+	s.c.Advance(999 * time.Millisecond)
+	_, ok = d.TryTake()
+	s.False(ok)
+	s.c.Advance(time.Millisecond)
+	v, ok = d.TryTake()
+	s.True(ok)
+	s.Equal(2, v.(Item).Attempts)
+
+	s.NoError(d.Ack(item.ID))
+	s.Equal(ErrItemNotFound, d.Ack(item.ID))
+	s.Equal(0, d.Size())
+	s.c.Advance(time.Second)
+	_, ok = d.TryTake()
+	s.False(ok)
+
+	s.Equal(0, d.Len(StatePending))
+	s.Equal(1, d.Len(StateFired))
+	s.Equal(0, d.Len(StateCanceled))
+}
+
+func (s *durableQueueTestSuite) TestRecover() {
+	d := s.open()
+	h1 := d.Offer([]byte("a"), s.n+100)
+	d.Offer("b", s.n+200)
+	h3 := d.Offer(map[string]int{"c": 1}, s.n+300)
+	d.Offer("d", s.n+400)
+	s.True(h1.Cancel())
+	s.True(h3.Reschedule(s.n + 50))
+	s.Equal(StatePending, h3.State())
+	s.Equal(s.n+50, h3.Expiration())
+
+	// the reschedule item is delivered but not acked
+	s.c.Advance(50 * time.Millisecond)
+	v, ok := d.TryTake()
+	s.True(ok)
+	s.Equal(Item{ID: 3, Payload: []byte(`{"c":1}`), Expiration: s.n + 50, Attempts: 1}, v.(Item))
+	s.NoError(d.Close())
+	s.Equal(ErrQueueClosed, d.Close())
+	s.Equal(ErrQueueClosed, d.Ack(2))
+	_, err := d.OfferPayload([]byte("e"), s.n)
+	s.Equal(ErrQueueClosed, err)
+	s.Equal(7, s.lines())
+
+	// append an partial written entry
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+	s.NoError(err)
+	_, err = f.WriteString(`{"op":"offer","id":`)
+	s.NoError(err)
+	s.NoError(f.Close())
+
+	d = s.open()
+	defer d.Close()
+	s.Equal(3, s.lines())
+	s.Equal(3, d.Size())
+	s.Equal(2, d.Len(StatePending))
+
+	var items []Item
+	s.c.Advance(time.Second)
+	vs, err := d.TakeN(context.Background(), 0)
+	s.NoError(err)
+	for _, v := range vs {
+		items = append(items, v.(Item))
+	}
+	s.Equal([]Item{
+		{ID: 2, Payload: []byte("b"), Expiration: s.n + 200, Attempts: 1},
+		{ID: 4, Payload: []byte("d"), Expiration: s.n + 400, Attempts: 1},
+		{ID: 3, Payload: []byte(`{"c":1}`), Expiration: s.n + 1050, Attempts: 2},
+	}, items)
+
+	// the id continues after recovered
+	h, err := d.OfferPayload(nil, s.n)
+	s.NoError(err)
+	s.Equal(uint64(5), h.Value().(Item).ID)
+}
+
+func (s *durableQueueTestSuite) TestCompact() {
+	d := s.open(WithCompactThreshold(4))
+	defer d.Close()
+
+	h, err := d.OfferPayload([]byte("a"), s.n+100)
+	s.NoError(err)
+	for i := 0; i < 2; i++ {
+		s.True(h.Reschedule(s.n + int64(i)))
+	}
+	s.Equal(3, s.lines())
+
+	// the fourth entry trigger the compaction
+	s.True(h.Reschedule(s.n + 10))
+	s.Equal(1, s.lines())
+	s.Equal(s.n+10, d.Peek().Expiration())
+
+	hs := d.Drain()
+	s.Equal([]Handle{h}, hs)
+	s.Equal(StateCanceled, h.State())
+	s.Equal(2, s.lines())
+	s.Equal(0, d.Size())
+	s.Nil(d.Peek())
+}
+
+func (s *durableQueueTestSuite) TestPoll() {
+	d := s.open()
+	defer d.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d.Poll(ctx)
+	}()
+
+	d.Offer("a", s.n+100)
+	s.c.BlockUntil(1)
+	s.c.Advance(100 * time.Millisecond)
+	item := (<-d.Chan()).(Item)
+	s.Equal([]byte("a"), item.Payload)
+	s.NoError(d.Ack(item.ID))
+
+	d.Offer("b", s.n+200)
+	s.c.BlockUntil(1)
+	s.c.Advance(100 * time.Millisecond)
+	item = (<-d.Chan()).(Item)
+	s.Equal([]byte("b"), item.Payload)
+
+	s.c.BlockUntil(1)
+	s.c.Advance(time.Second)
+	item = (<-d.Chan()).(Item)
+	s.Equal(2, item.Attempts)
+
+	cancel()
+	wg.Wait()
+}
+
+func (s *durableQueueTestSuite) TestTake() {
+	d := s.open()
+	defer d.Close()
+
+	errs := make(chan error, 1)
+	d.(*durableQueue).opt.ErrorHandler = func(err error) {
+		errs <- err
+	}
+	h := d.Offer(func() {}, s.n)
+	s.Equal(StateCanceled, h.State())
+	s.Error(<-errs)
+
+	h = d.Offer("a", s.n+100)
+	go func() {
+		s.c.BlockUntil(1)
+		s.c.Advance(100 * time.Millisecond)
+	}()
+	v, err := d.Take(context.Background())
+	s.NoError(err)
+	s.Equal(h.Value().(Item).ID, v.(Item).ID)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = d.Take(ctx)
+	s.Equal(context.Canceled, err)
+}
+
+// volatile is the Volatile element for test
+type volatile struct {
+	v int
+}
+
+func (v *volatile) Volatile() {}
+
+func (s *durableQueueTestSuite) TestVolatile() {
+	d := s.open()
+
+	v1, v2, v3 := &volatile{v: 1}, &volatile{v: 2}, &volatile{v: 3}
+	h1 := d.Offer(v1, s.n+100)
+	h2 := d.Offer(v2, s.n+200)
+	d.Offer(v3, s.n+300)
+	d.Offer("a", s.n+150)
+	s.Equal(1, s.lines())
+	s.Equal(4, d.Size())
+	s.Equal(4, d.Len(StatePending))
+	s.Equal(v1, h1.Value())
+	s.Equal(h1.Value(), d.Peek().Value())
+
+	s.True(h2.Cancel())
+	s.False(h2.Cancel())
+	s.Equal(1, d.Len(StateCanceled))
+
+	// the Volatile element is delivered by itself only once
+	s.c.Advance(100 * time.Millisecond)
+	v, ok := d.TryTake()
+	s.True(ok)
+	s.Equal(v1, v)
+	s.Equal(StateFired, h1.State())
+	s.False(h1.Cancel())
+	s.Equal(1, d.Len(StateFired))
+	s.Equal(2, d.Size())
+
+	hs := d.Drain()
+	s.Len(hs, 2)
+	s.Equal(0, d.Size())
+	s.Equal(0, d.Len(StatePending))
+	s.Equal(3, d.Len(StateCanceled))
+	s.c.Advance(time.Second)
+	_, ok = d.TryTake()
+	s.False(ok)
+
+	// the Volatile element is lost after reopened
+	h := d.Offer(v3, s.n+2000)
+	s.Equal(StatePending, h.State())
+	s.NoError(d.Close())
+	_, err := d.OfferContext(context.Background(), v3, s.n)
+	s.Equal(ErrQueueClosed, err)
+
+	d = s.open()
+	defer d.Close()
+	s.Equal(0, d.Size())
+}
+
+func TestDurableQueueTestSuite(t *testing.T) {
+	s := &durableQueueTestSuite{}
+	suite.Run(t, s)
+}
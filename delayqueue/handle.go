@@ -0,0 +1,151 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package delayqueue
+
+import (
+	"github.com/lsytj0413/ena/ds/queue/priorityqueue"
+)
+
+// State is the state of element in the DelayQueue
+type State int
+
+const (
+	// StatePending means the element is waiting in the queue
+	StatePending State = iota
+
+	// StateFired means the element has been fired, it's send to the output channel
+	StateFired
+
+	// StateCanceled means the element has been removed from the queue by Cancel or Drain
+	StateCanceled
+)
+
+// String return the representation of State
+func (s State) String() string {
+	switch s {
+	case StatePending:
+		return "Pending"
+	case StateFired:
+		return "Fired"
+	case StateCanceled:
+		return "Canceled"
+	}
+	return "Unknown"
+}
+
+// Handle is the element been offered into the DelayQueue, it can been used to
+// cancel or reschedule the element before it's fired.
+type Handle interface {
+	// Value return the value of element
+	Value() interface{}
+
+	// Expiration return the current expiration of element
+	Expiration() int64
+
+	// State return the current state of element
+	State() State
+
+	// Cancel removes the element from the queue, it returns false if the element
+	// has been fired or canceled.
+	Cancel() bool
+
+	// Reschedule changes the expiration of element, it returns false if the element
+	// has been fired or canceled.
+	Reschedule(expiration int64) bool
+}
+
+// handle implement the Handle interface, all the fields is protected by the mu of queue
+type handle struct {
+	q *delayQueue
+	v interface{}
+	e *priorityqueue.Element
+	s State
+
+	// me is the element in the maxpq of queue
+	me *priorityqueue.Element
+
+	// exp is the expiration of the element which is rejected by the queue, the e is nil for it
+	exp int64
+}
+
+// Value implement the Handle.Value
+func (h *handle) Value() interface{} {
+	return h.v
+}
+
+// Expiration implement the Handle.Expiration
+func (h *handle) Expiration() int64 {
+	h.q.mu.Lock()
+	defer h.q.mu.Unlock()
+
+	if h.e == nil {
+		return h.exp
+	}
+	return h.e.Priority()
+}
+
+// State implement the Handle.State
+func (h *handle) State() State {
+	h.q.mu.Lock()
+	defer h.q.mu.Unlock()
+
+	return h.s
+}
+
+// Cancel implement the Handle.Cancel
+func (h *handle) Cancel() bool {
+	h.q.mu.Lock()
+	defer h.q.mu.Unlock()
+
+	if h.s != StatePending {
+		return false
+	}
+
+	// the pending element is always in the queue, so the remove will not fail
+	h.q.remove(h)
+	h.q.release()
+	h.s = StateCanceled
+	h.q.canceled++
+	return true
+}
+
+// Reschedule implement the Handle.Reschedule
+func (h *handle) Reschedule(expiration int64) bool {
+	_update := func() (bool, int) {
+		h.q.mu.Lock()
+		defer h.q.mu.Unlock()
+
+		if h.s != StatePending {
+			return false, -1
+		}
+
+		h.q.update(h, expiration)
+		return true, h.e.Index()
+	}
+
+	ok, index := _update()
+	if ok {
+		h.q.wakeup(index)
+	}
+	return ok
+}
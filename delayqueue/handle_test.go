@@ -0,0 +1,132 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package delayqueue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/lsytj0413/ena/clock"
+)
+
+type handleTestSuite struct {
+	suite.Suite
+
+	c      clock.FakeClock
+	dq     DelayQueue
+	n      int64
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (s *handleTestSuite) SetupTest() {
+	poll = pollImpl
+	s.c = clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	s.dq = NewWithClock(1, s.c)
+	s.n = clockTimer{c: s.c, unit: time.Millisecond}.Now()
+
+	var ctx context.Context
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.dq.Poll(ctx)
+	}()
+}
+
+func (s *handleTestSuite) TearDownTest() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *handleTestSuite) TestCancel() {
+	h1 := s.dq.Offer(1, s.n+100)
+	h2 := s.dq.Offer(2, s.n+200)
+	s.Equal(1, h1.Value())
+	s.Equal(s.n+100, h1.Expiration())
+	s.Equal(StatePending, h1.State())
+
+	s.True(h1.Cancel())
+	s.False(h1.Cancel())
+	s.Equal(StateCanceled, h1.State())
+	s.False(h1.Reschedule(s.n))
+	s.Equal(1, s.dq.Size())
+
+	s.c.BlockUntil(1)
+	s.c.Advance(100 * time.Millisecond)
+	select {
+	case v := <-s.dq.Chan():
+		s.FailNow("expect the canceled element not fired", "%v", v)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.c.BlockUntil(1)
+	s.c.Advance(100 * time.Millisecond)
+	s.Equal(2, <-s.dq.Chan())
+	s.Equal(StateFired, h2.State())
+	s.False(h2.Cancel())
+	s.False(h2.Reschedule(s.n + 300))
+
+	s.Equal(0, s.dq.Len(StatePending))
+	s.Equal(1, s.dq.Len(StateFired))
+	s.Equal(1, s.dq.Len(StateCanceled))
+}
+
+func (s *handleTestSuite) TestReschedule() {
+	h1 := s.dq.Offer(1, s.n+100)
+	h2 := s.dq.Offer(2, s.n+200)
+
+	// move the second element to the head
+	s.True(h2.Reschedule(s.n + 50))
+	s.Equal(s.n+50, h2.Expiration())
+	s.Equal(h2, s.dq.Peek())
+
+	// the poll maybe still waiting for the first element, so advance over the expiration
+	s.c.BlockUntil(1)
+	s.c.Advance(60 * time.Millisecond)
+	s.Equal(2, <-s.dq.Chan())
+
+	// delay the first element
+	s.True(h1.Reschedule(s.n + 300))
+	s.c.BlockUntil(1)
+	s.c.Advance(50 * time.Millisecond)
+	select {
+	case v := <-s.dq.Chan():
+		s.FailNow("expect the rescheduled element not fired", "%v", v)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.c.BlockUntil(1)
+	s.c.Advance(200 * time.Millisecond)
+	s.Equal(1, <-s.dq.Chan())
+	s.Equal(StateFired, h1.State())
+}
+
+func TestHandleTestSuite(t *testing.T) {
+	s := &handleTestSuite{}
+	suite.Run(t, s)
+}
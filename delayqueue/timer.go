@@ -24,6 +24,8 @@ package delayqueue
 
 import (
 	"time"
+
+	"github.com/lsytj0413/ena/clock"
 )
 
 // Timer for provide the current ms
@@ -42,3 +44,14 @@ func (t timer) Now() int64 {
 var (
 	defaultTimer = timer{}
 )
+
+// clockTimer implement the Timer with clock.Clock, the current time is in unit
+type clockTimer struct {
+	c    clock.Clock
+	unit time.Duration
+}
+
+// Now implement Timer.Now
+func (t clockTimer) Now() int64 {
+	return int64(time.Duration(t.c.Now().UnixNano()) / t.unit)
+}
@@ -14,8 +14,6 @@
 
 package queue
 
-import "fmt"
-
 // CircularDeque is interface for CircularDeque Data Structure
 type CircularDeque interface {
 	Empty() bool
@@ -31,15 +29,12 @@ type CircularDeque interface {
 }
 
 type circularDeque struct {
-	datas []interface{}
-
-	start int
-	end   int
-	size  int
+	ring
 }
 
 func (c *circularDeque) PushFront(v interface{}) bool {
-	if c.IsFull() {
+	// drop the back element if overwrite
+	if c.size == len(c.datas) && !c.makeRoom(c.PollBack) {
 		return false
 	}
 
@@ -53,7 +48,8 @@ func (c *circularDeque) PushFront(v interface{}) bool {
 }
 
 func (c *circularDeque) PushBack(v interface{}) bool {
-	if c.IsFull() {
+	// drop the front element if overwrite
+	if c.size == len(c.datas) && !c.makeRoom(c.PollFront) {
 		return false
 	}
 
@@ -99,6 +95,7 @@ func (c *circularDeque) PollBack() interface{} {
 	v := c.datas[c.end]
 	c.datas[c.end] = nil
 
+	c.shrink()
 	return v
 }
 
@@ -115,11 +112,14 @@ func (c *circularDeque) PollFront() interface{} {
 	if c.start >= len(c.datas) {
 		c.start = 0
 	}
+
+	c.shrink()
 	return v
 }
 
+// IsFull return false if the deque is growable, because it never been full
 func (c *circularDeque) IsFull() bool {
-	return c.size == len(c.datas)
+	return !c.options.Growable && c.size == len(c.datas)
 }
 
 func (c *circularDeque) Empty() bool {
@@ -139,16 +139,15 @@ const (
 	maxCircularDequeCap int = 10000
 )
 
-// NewCircularDeque will construct CircularDeque object
-func NewCircularDeque(cap int) (CircularDeque, error) {
-	if cap < minCircularDequeCap || cap > maxCircularDequeCap {
-		return nil, fmt.Errorf("Cap should within [%d, %d]", minCircularDequeCap, maxCircularDequeCap)
+// NewCircularDeque will construct CircularDeque object, the cap is not limited by the max
+// capacity with WithGrowable.
+func NewCircularDeque(cap int, opts ...Option) (CircularDeque, error) {
+	options, err := newOption(cap, minCircularDequeCap, maxCircularDequeCap, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	return &circularDeque{
-		datas: make([]interface{}, cap),
-		start: 0,
-		end:   0,
-		size:  0,
+		ring: newRing(cap, options),
 	}, nil
 }
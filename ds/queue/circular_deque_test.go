@@ -150,6 +150,78 @@ func (s *circularDequeTestSuite) TestPeek() {
 	s.Equal(1, s.q.PeekFront().(int))
 }
 
+func (s *circularDequeTestSuite) TestNewFailed() {
+	_, err := NewCircularDeque(0)
+	s.Error(err)
+	_, err = NewCircularDeque(maxCircularDequeCap + 1)
+	s.Error(err)
+	_, err = NewCircularDeque(1, WithShrink(), WithOverwrite())
+	s.Error(err)
+}
+
+func (s *circularDequeTestSuite) TestGrowable() {
+	v, err := NewCircularDeque(maxCircularDequeCap+1, WithGrowable())
+	s.NoError(err)
+	s.Equal(16384, v.Cap())
+
+	v, err = NewCircularDeque(testCap, WithGrowable())
+	s.NoError(err)
+	s.Equal(4, v.Cap())
+	for i := 0; i < 50; i++ {
+		s.True(v.PushFront(-i))
+		s.True(v.PushBack(i))
+		s.False(v.IsFull())
+	}
+	s.Equal(100, v.Len())
+	s.Equal(128, v.Cap())
+	for i := 49; i >= 0; i-- {
+		s.Equal(-i, v.PollFront())
+		s.Equal(i, v.PollBack())
+	}
+	s.Nil(v.PollFront())
+	s.Equal(128, v.Cap())
+}
+
+func (s *circularDequeTestSuite) TestShrink() {
+	v, err := NewCircularDeque(testCap, WithShrink())
+	s.NoError(err)
+	for i := 0; i < 100; i++ {
+		s.True(v.PushBack(i))
+	}
+	s.Equal(128, v.Cap())
+	for i := 99; i >= 50; i-- {
+		s.Equal(i, v.PollBack())
+	}
+	s.Equal(128, v.Cap())
+	for i := 0; i < 18; i++ {
+		s.Equal(i, v.PollFront())
+	}
+	s.Equal(64, v.Cap())
+	s.Equal(18, v.PeekFront())
+	s.Equal(49, v.PeekBack())
+	for v.PollBack() != nil {
+	}
+	s.Equal(4, v.Cap())
+}
+
+func (s *circularDequeTestSuite) TestOverwrite() {
+	v, err := NewCircularDeque(testCap, WithOverwrite())
+	s.NoError(err)
+	for i := 0; i < 5; i++ {
+		s.True(v.PushBack(i))
+	}
+	s.True(v.IsFull())
+	s.Equal(2, v.PeekFront())
+	s.Equal(4, v.PeekBack())
+
+	// drop the back element by PushFront
+	s.True(v.PushFront(10))
+	s.Equal(10, v.PollFront())
+	s.Equal(2, v.PollFront())
+	s.Equal(3, v.PollFront())
+	s.Nil(v.PollFront())
+}
+
 func TestCircularDequeTestSuite(t *testing.T) {
 	s := &circularDequeTestSuite{}
 	suite.Run(t, s)
@@ -14,10 +14,6 @@
 
 package queue
 
-import (
-	"fmt"
-)
-
 // CircularQueue is interface for CircularQueue Data Structure
 type CircularQueue interface {
 	Empty() bool
@@ -30,15 +26,12 @@ type CircularQueue interface {
 }
 
 type circularQueue struct {
-	datas []interface{}
-
-	start int
-	end   int
-	size  int
+	ring
 }
 
 func (c *circularQueue) Push(v interface{}) bool {
-	if c.IsFull() {
+	// drop the oldest element if overwrite
+	if c.size == len(c.datas) && !c.makeRoom(c.Poll) {
 		return false
 	}
 
@@ -66,16 +59,20 @@ func (c *circularQueue) Poll() interface{} {
 
 	c.size--
 	v := c.datas[c.start]
+	c.datas[c.start] = nil
 
 	c.start++
 	if c.start >= len(c.datas) {
 		c.start = 0
 	}
+
+	c.shrink()
 	return v
 }
 
+// IsFull return false if the queue is growable, because it never been full
 func (c *circularQueue) IsFull() bool {
-	return c.size == len(c.datas)
+	return !c.options.Growable && c.size == len(c.datas)
 }
 
 func (c *circularQueue) Empty() bool {
@@ -95,16 +92,15 @@ const (
 	maxCircularQueueCap int = 10000
 )
 
-// NewCircularQueue will construct CircularQueue object
-func NewCircularQueue(cap int) (CircularQueue, error) {
-	if cap < minCircularQueueCap || cap > maxCircularQueueCap {
-		return nil, fmt.Errorf("Cap should within [%d, %d]", minCircularQueueCap, maxCircularQueueCap)
+// NewCircularQueue will construct CircularQueue object, the cap is not limited by the max
+// capacity with WithGrowable.
+func NewCircularQueue(cap int, opts ...Option) (CircularQueue, error) {
+	options, err := newOption(cap, minCircularQueueCap, maxCircularQueueCap, opts)
+	if err != nil {
+		return nil, err
 	}
 
 	return &circularQueue{
-		datas: make([]interface{}, cap),
-		start: 0,
-		end:   0,
-		size:  0,
+		ring: newRing(cap, options),
 	}, nil
 }
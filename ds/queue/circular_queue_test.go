@@ -133,6 +133,81 @@ func (s *circularQueueTestSuite) TestPeek() {
 	s.Nil(s.q.Peek())
 }
 
+func (s *circularQueueTestSuite) TestNewFailed() {
+	_, err := NewCircularQueue(0)
+	s.Error(err)
+	_, err = NewCircularQueue(maxCircularQueueCap + 1)
+	s.Error(err)
+	_, err = NewCircularQueue(1, WithGrowable(), WithOverwrite())
+	s.Error(err)
+	_, err = NewCircularQueue(0, WithGrowable())
+	s.Error(err)
+}
+
+func (s *circularQueueTestSuite) TestGrowable() {
+	v, err := NewCircularQueue(maxCircularQueueCap+1, WithGrowable())
+	s.NoError(err)
+	s.Equal(16384, v.Cap())
+
+	v, err = NewCircularQueue(testCap, WithGrowable())
+	s.NoError(err)
+	s.Equal(4, v.Cap())
+
+	// wrap the ring before grow
+	s.True(v.Push(-1))
+	s.Equal(-1, v.Poll())
+	for i := 0; i < 100; i++ {
+		s.True(v.Push(i))
+		s.False(v.IsFull())
+	}
+	s.Equal(100, v.Len())
+	s.Equal(128, v.Cap())
+	for i := 0; i < 100; i++ {
+		s.Equal(i, v.Poll())
+	}
+	s.Nil(v.Poll())
+
+	// the capacity never shrink without WithShrink
+	s.Equal(128, v.Cap())
+}
+
+func (s *circularQueueTestSuite) TestShrink() {
+	v, err := NewCircularQueue(testCap, WithShrink())
+	s.NoError(err)
+	for i := 0; i < 100; i++ {
+		s.True(v.Push(i))
+	}
+	s.Equal(128, v.Cap())
+
+	for i := 0; i < 68; i++ {
+		s.Equal(i, v.Poll())
+	}
+	s.Equal(64, v.Cap())
+	for i := 68; i < 100; i++ {
+		s.Equal(i, v.Poll())
+	}
+
+	// not less than the initial capacity
+	s.Equal(4, v.Cap())
+	s.True(v.Push(100))
+	s.Equal(100, v.Peek())
+}
+
+func (s *circularQueueTestSuite) TestOverwrite() {
+	v, err := NewCircularQueue(testCap, WithOverwrite())
+	s.NoError(err)
+	s.Equal(testCap, v.Cap())
+	for i := 0; i < 10; i++ {
+		s.True(v.Push(i))
+	}
+	s.True(v.IsFull())
+	s.Equal(testCap, v.Len())
+	s.Equal(7, v.Poll())
+	s.Equal(8, v.Poll())
+	s.Equal(9, v.Poll())
+	s.Nil(v.Poll())
+}
+
 func TestCircularQueueTestSuite(t *testing.T) {
 	s := &circularQueueTestSuite{}
 	suite.Run(t, s)
@@ -0,0 +1,299 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type backendTestSuite struct {
+	suite.Suite
+}
+
+type testBackend struct {
+	name   string
+	stable bool
+	opts   []Option
+}
+
+var testBackends = []testBackend{
+	{name: "binary", opts: []Option{WithBackend(BackendBinary)}},
+	{name: "dary-2", opts: []Option{WithBackend(BackendDAry), WithArity(2)}},
+	{name: "dary-4", opts: []Option{WithBackend(BackendDAry)}},
+	{name: "dary-8", opts: []Option{WithBackend(BackendDAry), WithArity(8)}},
+	{name: "pairing", opts: []Option{WithBackend(BackendPairing)}},
+	{name: "radix", opts: []Option{WithBackend(BackendRadix)}},
+	{name: "binary-stable", stable: true, opts: []Option{WithBackend(BackendBinary), WithStable()}},
+	{name: "dary-stable", stable: true, opts: []Option{WithBackend(BackendDAry), WithStable()}},
+	{name: "pairing-stable", stable: true, opts: []Option{WithBackend(BackendPairing), WithStable()}},
+	{name: "radix-stable", stable: true, opts: []Option{WithBackend(BackendRadix), WithStable()}},
+}
+
+// TestRandom compares the random operations of backends with the sorted slice
+func (s *backendTestSuite) TestRandom() {
+	for _, tb := range testBackends {
+		stable := tb.stable
+		pq := NewPriorityQueueWithOptions(0, tb.opts...)
+		s.Run(tb.name, func() {
+			r := rand.New(rand.NewSource(1))
+
+			// the priority is monotone, so it can been used by radix heap
+			var last int64
+			live := []*Element{}
+			sortLive := func() {
+				sort.SliceStable(live, func(i, j int) bool {
+					if live[i].priority != live[j].priority {
+						return live[i].priority < live[j].priority
+					}
+					return live[i].seq < live[j].seq
+				})
+			}
+			for i := 0; i < 5000; i++ {
+				switch op := r.Intn(10); {
+				case op < 4:
+					live = append(live, pq.Add(i, last+r.Int63n(100)))
+				case op < 6:
+					e := pq.Pop()
+					if len(live) == 0 {
+						s.Nil(e)
+						continue
+					}
+					sortLive()
+					if stable {
+						s.Equal(live[0], e)
+					} else {
+						s.Equal(live[0].priority, e.Priority())
+					}
+					for j, l := range live {
+						if l == e {
+							live = append(live[:j], live[j+1:]...)
+							break
+						}
+					}
+					last = e.Priority()
+					s.Equal(-1, e.Index())
+				case op < 8:
+					if len(live) == 0 {
+						continue
+					}
+					j := r.Intn(len(live))
+					s.NoError(pq.Remove(live[j]))
+					s.Error(pq.Remove(live[j]))
+					live = append(live[:j], live[j+1:]...)
+				default:
+					if len(live) == 0 {
+						continue
+					}
+					s.NoError(pq.Update(live[r.Intn(len(live))], last+r.Int63n(100)))
+				}
+
+				s.Equal(len(live), pq.Size())
+				if len(live) > 0 {
+					sortLive()
+					if stable {
+						s.Equal(live[0], pq.Peek())
+					} else {
+						s.Equal(live[0].priority, pq.Peek().Priority())
+					}
+				}
+			}
+		})
+	}
+}
+
+func (s *backendTestSuite) TestLess() {
+	for _, b := range []Backend{BackendBinary, BackendDAry, BackendPairing} {
+		s.Run(fmt.Sprintf("backend-%d", b), func() {
+			pq := NewPriorityQueueWithOptions(0, WithBackend(b), WithLess(func(a *Element, b *Element) bool {
+				return a.Priority() > b.Priority()
+			}))
+			es := make([]*Element, 0, 10)
+			for i := 0; i < 10; i++ {
+				es = append(es, pq.Add(i, int64(i)))
+			}
+			s.NoError(pq.Update(es[9], -1))
+			s.NoError(pq.Update(es[0], 100))
+			s.Equal(0, pq.Peek().Index())
+
+			var vs []interface{}
+			for e := pq.Pop(); e != nil; e = pq.Pop() {
+				vs = append(vs, e.Value)
+			}
+			s.Equal([]interface{}{0, 8, 7, 6, 5, 4, 3, 2, 1, 9}, vs)
+		})
+	}
+}
+
+// TestBatch checks the NewFromSlice, PopN, Merge, Range and Clear of backends
+func (s *backendTestSuite) TestBatch() {
+	for _, tb := range testBackends {
+		stable := tb.stable
+		opts := tb.opts
+		s.Run(tb.name, func() {
+			r := rand.New(rand.NewSource(1))
+			values := make([]interface{}, 0, 1000)
+			for i := 0; i < 1000; i++ {
+				values = append(values, r.Int63n(100))
+			}
+			priority := func(v interface{}) int64 {
+				return v.(int64)
+			}
+
+			pq := NewFromSlice(values, priority, opts...)
+			s.Equal(len(values), pq.Size())
+
+			// Range visits all the elements
+			seen := map[*Element]bool{}
+			pq.Range(func(e *Element) bool {
+				s.False(seen[e])
+				seen[e] = true
+				return true
+			})
+			s.Equal(len(values), len(seen))
+
+			n := 0
+			pq.Range(func(e *Element) bool {
+				n++
+				return n < 10
+			})
+			s.Equal(10, n)
+
+			// pop some elements, so the merged elements maybe less than the last popped of radix heap
+			popped := pq.PopN(100)
+			s.Equal(100, len(popped))
+			s.Equal(len(values)-100, pq.Size())
+
+			other := NewPriorityQueueWithOptions(0, opts...)
+			es := make([]*Element, 0, 200)
+			for i := 0; i < 200; i++ {
+				es = append(es, other.Add(int64(1000+i), r.Int63n(100)))
+			}
+			s.NoError(pq.Merge(other))
+			s.Error(pq.Merge(pq))
+			s.Equal(0, other.Size())
+			s.Nil(other.Peek())
+			s.Equal(len(values)+100, pq.Size())
+
+			// the merged element belongs to pq now
+			s.NoError(pq.Update(es[0], -1))
+			s.Equal(es[0], pq.Peek())
+			s.NoError(pq.Remove(es[0]))
+			s.Error(other.Remove(es[1]))
+
+			all := pq.PopN(len(values) + 1000)
+			s.Equal(len(values)+99, len(all))
+			s.Equal(0, pq.Size())
+			s.Nil(pq.PopN(1))
+			s.True(sort.SliceIsSorted(all, func(i, j int) bool {
+				return all[i].Priority() < all[j].Priority()
+			}))
+			if stable {
+				// the merged elements is after the elements of queue with equal priority
+				for i := 1; i < len(all); i++ {
+					if all[i].Priority() != all[i-1].Priority() {
+						continue
+					}
+					a, b := all[i-1].Value.(int64), all[i].Value.(int64)
+					s.False(a >= 1000 && b < 1000)
+				}
+			}
+
+			// the queue is usable after Clear
+			es = es[:0]
+			for i := 0; i < 10; i++ {
+				es = append(es, pq.Add(i, int64(i)))
+			}
+			pq.Clear()
+			s.Equal(0, pq.Size())
+			s.Nil(pq.Peek())
+			pq.Range(func(e *Element) bool {
+				s.Fail("Range after Clear")
+				return true
+			})
+			s.Equal(-1, es[0].Index())
+			s.Error(pq.Remove(es[0]))
+
+			pq.Add(1, 1)
+			pq.Add(0, 0)
+			s.Equal(0, pq.Pop().Value)
+			s.Equal(1, pq.Pop().Value)
+		})
+	}
+}
+
+func (s *backendTestSuite) TestFromSliceStable() {
+	values := []interface{}{"a", "b", "c", "d", "e", "f"}
+	priorities := map[interface{}]int64{"a": 2, "b": 1, "c": 2, "d": 1, "e": 2, "f": 0}
+	for _, tb := range testBackends {
+		if !tb.stable {
+			continue
+		}
+		pq := NewFromSlice(values, func(v interface{}) int64 {
+			return priorities[v]
+		}, tb.opts...)
+
+		var vs []interface{}
+		for _, e := range pq.PopN(10) {
+			vs = append(vs, e.Value)
+		}
+		s.Equal([]interface{}{"f", "b", "d", "a", "c", "e"}, vs, tb.name)
+	}
+}
+
+func (s *backendTestSuite) TestPairingNodes() {
+	pq := NewPriorityQueueWithOptions(0, WithBackend(BackendPairing)).(*priorityQueue)
+	h := pq.b.(*pairingHeap)
+
+	es := make([]*Element, 0, 4)
+	for i := 0; i < 4; i++ {
+		es = append(es, pq.Add(i, int64(i)))
+		s.Equal(i, es[i].Index())
+		s.Equal(uint64(0), es[i].seq)
+	}
+
+	// the position of removed element is reused
+	s.NoError(pq.Remove(es[1]))
+	s.Nil(h.nodes[1])
+	e := pq.Add(4, -1)
+	s.Equal(1, e.Index())
+	s.Equal(e, pq.Peek())
+
+	// the position doesn't change after updated
+	s.NoError(pq.Update(es[3], -2))
+	s.Equal(3, es[3].Index())
+	s.Equal(es[3], pq.Peek())
+
+	pq.Clear()
+	s.Equal(0, len(h.nodes))
+	s.Equal(0, len(h.free))
+}
+
+func TestBackendTestSuite(t *testing.T) {
+	s := &backendTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,198 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// ConcurrentPriorityQueue is the goroutine-safe PriorityQueue, the element returned by it
+// should only been accessed by the methods of queue, because it maybe modified concurrently.
+//
+// The NewConcurrentPriorityQueue is guarded by a single mutex, and the NewSkipListPriorityQueue
+// only locks the nodes around the modified position, so it has lower contention when accessed by
+// many goroutines. The caller which must change its own state with the queue atomically
+// (EX: the delayqueue) should still use the PriorityQueue with its own lock.
+type ConcurrentPriorityQueue interface {
+	PriorityQueue
+
+	// PopWait return the lowest priority element and remove it, it will wait until there is
+	// element in the queue or the ctx is done.
+	PopWait(ctx context.Context) (*Element, error)
+}
+
+// concurrentPriorityQueue implement the ConcurrentPriorityQueue by the priorityQueue with mutex
+type concurrentPriorityQueue struct {
+	mu sync.Mutex
+	pq *priorityQueue
+
+	// notifyC is closed to wakeup the waiting PopWait when element added, and waiters
+	// is the count of waiting PopWait
+	notifyC chan struct{}
+	waiters int
+}
+
+// NewConcurrentPriorityQueue construct a ConcurrentPriorityQueue with the options
+func NewConcurrentPriorityQueue(size int, opts ...Option) ConcurrentPriorityQueue {
+	return &concurrentPriorityQueue{
+		pq:      NewPriorityQueueWithOptions(size, opts...).(*priorityQueue),
+		notifyC: make(chan struct{}),
+	}
+}
+
+// Add element to the PriorityQueue, it will return the element witch been added
+func (cpq *concurrentPriorityQueue) Add(x interface{}, priority int64) *Element {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	e := cpq.pq.Add(x, priority)
+	cpq.notify()
+	return e
+}
+
+// notify wakeup the waiting PopWait, the mu must been held
+func (cpq *concurrentPriorityQueue) notify() {
+	if cpq.waiters > 0 {
+		close(cpq.notifyC)
+		cpq.notifyC = make(chan struct{})
+	}
+}
+
+// Peek return the lowest priority element
+func (cpq *concurrentPriorityQueue) Peek() *Element {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	return cpq.pq.Peek()
+}
+
+// Pop return the lowest priority element and remove it
+func (cpq *concurrentPriorityQueue) Pop() *Element {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	return cpq.pq.Pop()
+}
+
+// PopWait return the lowest priority element and remove it, it will wait until there is
+// element in the queue or the ctx is done.
+func (cpq *concurrentPriorityQueue) PopWait(ctx context.Context) (*Element, error) {
+	for {
+		cpq.mu.Lock()
+		if e := cpq.pq.Pop(); e != nil {
+			cpq.mu.Unlock()
+			return e, nil
+		}
+		cpq.waiters++
+		notifyC := cpq.notifyC
+		cpq.mu.Unlock()
+
+		var err error
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-notifyC:
+		}
+
+		cpq.mu.Lock()
+		cpq.waiters--
+		cpq.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Remove will remove the element from the priority queue
+func (cpq *concurrentPriorityQueue) Remove(e *Element) error {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	return cpq.pq.Remove(e)
+}
+
+// Update the element in the priority queue with the new priority
+func (cpq *concurrentPriorityQueue) Update(e *Element, priority int64) error {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	return cpq.pq.Update(e, priority)
+}
+
+// Size return the element size of queue
+func (cpq *concurrentPriorityQueue) Size() int {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	return cpq.pq.Size()
+}
+
+// PopN return at most k lowest priority elements by order and remove them
+func (cpq *concurrentPriorityQueue) PopN(k int) []*Element {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	return cpq.pq.PopN(k)
+}
+
+// Merge moves all the elements of other into the queue, the other will been empty after
+// merged. The other is locked only when the elements been taken from it, so the queues
+// can been merged into each other concurrently without deadlock.
+func (cpq *concurrentPriorityQueue) Merge(other PriorityQueue) error {
+	if other == PriorityQueue(cpq) {
+		return fmt.Errorf("PriorityQueue.Merge: QueueMatchFailed: Other[%p], Queue[%p]", other, cpq)
+	}
+
+	es, err := takeFrom(other, cpq.pq)
+	if err != nil {
+		return err
+	}
+
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	cpq.pq.absorb(es)
+	if len(es) > 0 {
+		cpq.notify()
+	}
+	return nil
+}
+
+// Range calls f for each element in the queue by unspecified order until f return false,
+// the queue is locked during the Range so it must not been accessed in f.
+func (cpq *concurrentPriorityQueue) Range(f func(e *Element) bool) {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	cpq.pq.Range(f)
+}
+
+// Clear removes all the elements from the queue
+func (cpq *concurrentPriorityQueue) Clear() {
+	cpq.mu.Lock()
+	defer cpq.mu.Unlock()
+
+	cpq.pq.Clear()
+}
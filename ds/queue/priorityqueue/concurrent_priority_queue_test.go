@@ -0,0 +1,182 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type concurrentPriorityQueueTestSuite struct {
+	suite.Suite
+
+	pq       ConcurrentPriorityQueue
+	newQueue func() ConcurrentPriorityQueue
+}
+
+func (s *concurrentPriorityQueueTestSuite) SetupTest() {
+	s.pq = s.newQueue()
+}
+
+func (s *concurrentPriorityQueueTestSuite) TestOperation() {
+	s.Nil(s.pq.Peek())
+	s.Nil(s.pq.Pop())
+
+	e1 := s.pq.Add(1, 10)
+	e2 := s.pq.Add(2, 20)
+	s.pq.Add(3, 30)
+	s.Equal(3, s.pq.Size())
+	s.Equal(e1, s.pq.Peek())
+
+	s.NoError(s.pq.Update(e2, 0))
+	s.Equal(e2, s.pq.Peek())
+	s.NoError(s.pq.Remove(e1))
+	s.Error(s.pq.Remove(e1))
+	s.Error(s.pq.Update(e1, 0))
+
+	s.Equal(2, s.pq.Pop().Value)
+	s.Equal(3, s.pq.Pop().Value)
+	s.Equal(0, s.pq.Size())
+}
+
+func (s *concurrentPriorityQueueTestSuite) TestPopWait() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	e, err := s.pq.PopWait(ctx)
+	s.Equal(context.DeadlineExceeded, err)
+	s.Nil(e)
+
+	s.pq.Add(1, 1)
+	e, err = s.pq.PopWait(context.Background())
+	s.NoError(err)
+	s.Equal(1, e.Value)
+
+	// wakeup by the added element
+	ch := make(chan *Element)
+	go func() {
+		e, err := s.pq.PopWait(context.Background())
+		s.NoError(err)
+		ch <- e
+	}()
+	time.Sleep(10 * time.Millisecond)
+	s.pq.Add(2, 2)
+	s.Equal(2, (<-ch).Value)
+}
+
+func (s *concurrentPriorityQueueTestSuite) TestBatch() {
+	for i := 0; i < 5; i++ {
+		s.pq.Add(i, int64(i))
+	}
+	n := 0
+	s.pq.Range(func(e *Element) bool {
+		n++
+		return true
+	})
+	s.Equal(5, n)
+
+	var vs []interface{}
+	for _, e := range s.pq.PopN(2) {
+		vs = append(vs, e.Value)
+	}
+	s.Equal([]interface{}{0, 1}, vs)
+
+	s.Error(s.pq.Merge(s.pq))
+	other := s.newQueue()
+	e := other.Add(10, -1)
+	s.NoError(s.pq.Merge(other))
+	s.Equal(0, other.Size())
+	s.Equal(4, s.pq.Size())
+	s.Equal(e, s.pq.Peek())
+	s.NoError(s.pq.Update(e, 100))
+
+	s.NoError(s.pq.Merge(NewPriorityQueue(0)))
+	s.pq.Clear()
+	s.Equal(0, s.pq.Size())
+
+	// wakeup by the merged element
+	ch := make(chan *Element)
+	go func() {
+		e, err := s.pq.PopWait(context.Background())
+		s.NoError(err)
+		ch <- e
+	}()
+	time.Sleep(10 * time.Millisecond)
+	pq := NewPriorityQueue(0)
+	pq.Add(20, 20)
+	s.NoError(s.pq.Merge(pq))
+	s.Equal(20, (<-ch).Value)
+}
+
+func (s *concurrentPriorityQueueTestSuite) TestConcurrent() {
+	const (
+		producers = 4
+		count     = 1000
+	)
+
+	var wg sync.WaitGroup
+	values := make(chan int, producers*count)
+	for i := 0; i < producers; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				v := i*count + j
+				s.pq.Add(v, int64(v))
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < count; j++ {
+				e, err := s.pq.PopWait(context.Background())
+				s.NoError(err)
+				values <- e.Value.(int)
+			}
+		}()
+	}
+	wg.Wait()
+	close(values)
+
+	vs := make([]int, 0, producers*count)
+	for v := range values {
+		vs = append(vs, v)
+	}
+	sort.Ints(vs)
+	for i, v := range vs {
+		s.Equal(i, v)
+	}
+	s.Equal(0, s.pq.Size())
+}
+
+func TestConcurrentPriorityQueueTestSuite(t *testing.T) {
+	s := &concurrentPriorityQueueTestSuite{
+		newQueue: func() ConcurrentPriorityQueue {
+			return NewConcurrentPriorityQueue(0)
+		},
+	}
+	suite.Run(t, s)
+}
@@ -0,0 +1,145 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+// dAryHeap is the backend by d-ary heap, the children of element i is [d*i+1, d*i+d]
+type dAryHeap struct {
+	pq *priorityQueue
+	d  int
+}
+
+func (h *dAryHeap) swap(i int, j int) {
+	e := h.pq.e
+	e[i], e[j] = e[j], e[i]
+	e[i].index = i
+	e[j].index = j
+}
+
+// up moves the element i to the root until it's not less than the parent
+func (h *dAryHeap) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / h.d
+		if !h.pq.lessElem(h.pq.e[i], h.pq.e[parent]) {
+			break
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+}
+
+// down moves the element i to the leaf until it's not greater than the children,
+// it returns true if the element is moved
+func (h *dAryHeap) down(i int) bool {
+	i0 := i
+	n := len(h.pq.e)
+	for {
+		first := h.d*i + 1
+		if first >= n || first < 0 {
+			break
+		}
+
+		// find the lowest child
+		min := first
+		for c := first + 1; c < first+h.d && c < n; c++ {
+			if h.pq.lessElem(h.pq.e[c], h.pq.e[min]) {
+				min = c
+			}
+		}
+		if !h.pq.lessElem(h.pq.e[min], h.pq.e[i]) {
+			break
+		}
+		h.swap(i, min)
+		i = min
+	}
+	return i > i0
+}
+
+func (h *dAryHeap) push(e *Element) {
+	e.index = len(h.pq.e)
+	h.pq.e = append(h.pq.e, e)
+	h.up(e.index)
+}
+
+func (h *dAryHeap) peek() *Element {
+	if len(h.pq.e) == 0 {
+		return nil
+	}
+	return h.pq.e[0]
+}
+
+func (h *dAryHeap) pop() *Element {
+	if len(h.pq.e) == 0 {
+		return nil
+	}
+
+	e := h.pq.e[0]
+	h.remove(e)
+	return e
+}
+
+func (h *dAryHeap) remove(e *Element) {
+	i := e.index
+	n := len(h.pq.e) - 1
+	if i != n {
+		h.swap(i, n)
+	}
+
+	// set element to nil for GC
+	h.pq.e[n] = nil
+	h.pq.e = h.pq.e[:n]
+	if i != n && !h.down(i) {
+		h.up(i)
+	}
+}
+
+func (h *dAryHeap) fix(e *Element, old int64) {
+	if !h.down(e.index) {
+		h.up(e.index)
+	}
+}
+
+func (h *dAryHeap) size() int {
+	return len(h.pq.e)
+}
+
+func (h *dAryHeap) pushAll(es []*Element) {
+	if !heapifiable(len(h.pq.e), len(es)) {
+		for _, e := range es {
+			h.push(e)
+		}
+		return
+	}
+
+	appendElems(h.pq, es)
+	for i := (len(h.pq.e) - 2) / h.d; i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+func (h *dAryHeap) each(f func(e *Element) bool) {
+	eachElems(h.pq, f)
+}
+
+func (h *dAryHeap) reset() {
+	resetElems(h.pq)
+}
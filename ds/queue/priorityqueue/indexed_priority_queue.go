@@ -0,0 +1,134 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+// IndexedPriorityQueue is the PriorityQueue which the element is identified by the comparable key,
+// so the caller can update or remove the element by key without holding the element.
+type IndexedPriorityQueue[K comparable, V any] interface {
+	// AddOrUpdate add the element with key, or update the value and priority of the exists element,
+	// it returns true if the element is added.
+	AddOrUpdate(key K, value V, priority int64) bool
+
+	// RemoveKey removes the element with key, it returns false if the key doesn't exist
+	RemoveKey(key K) (V, bool)
+
+	// Get return the value and priority of the element with key
+	Get(key K) (V, int64, bool)
+
+	// Contains reports whether the element with key exists
+	Contains(key K) bool
+
+	// Peek return the lowest priority element, it returns false if the queue is empty
+	Peek() (K, V, int64, bool)
+
+	// Pop return the lowest priority element and remove it, it returns false if the queue is empty
+	Pop() (K, V, int64, bool)
+
+	// Size return the element size of queue
+	Size() int
+}
+
+// indexedEntry is the value of element in the indexedPriorityQueue
+type indexedEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// indexedPriorityQueue implement the IndexedPriorityQueue by the TypedPriorityQueue and the index map
+type indexedPriorityQueue[K comparable, V any] struct {
+	pq    TypedPriorityQueue[indexedEntry[K, V]]
+	index map[K]*TypedElement[indexedEntry[K, V]]
+}
+
+// NewIndexedPriorityQueue construct a IndexedPriorityQueue, the priority is compared ascending and
+// the WithLess option is ignored.
+func NewIndexedPriorityQueue[K comparable, V any](size int, opts ...Option) IndexedPriorityQueue[K, V] {
+	return &indexedPriorityQueue[K, V]{
+		pq:    NewTypedPriorityQueue[indexedEntry[K, V]](size, nil, opts...),
+		index: make(map[K]*TypedElement[indexedEntry[K, V]], size),
+	}
+}
+
+func (ipq *indexedPriorityQueue[K, V]) AddOrUpdate(key K, value V, priority int64) bool {
+	if e, ok := ipq.index[key]; ok {
+		e.Value.value = value
+		// the element is always in the queue, so the update will not fail
+		_ = ipq.pq.Update(e, priority)
+		return false
+	}
+
+	ipq.index[key] = ipq.pq.Add(indexedEntry[K, V]{key: key, value: value}, priority)
+	return true
+}
+
+func (ipq *indexedPriorityQueue[K, V]) RemoveKey(key K) (V, bool) {
+	e, ok := ipq.index[key]
+	if !ok {
+		var v V
+		return v, false
+	}
+
+	_ = ipq.pq.Remove(e)
+	delete(ipq.index, key)
+	return e.Value.value, true
+}
+
+func (ipq *indexedPriorityQueue[K, V]) Get(key K) (V, int64, bool) {
+	e, ok := ipq.index[key]
+	if !ok {
+		var v V
+		return v, 0, false
+	}
+	return e.Value.value, e.Priority(), true
+}
+
+func (ipq *indexedPriorityQueue[K, V]) Contains(key K) bool {
+	_, ok := ipq.index[key]
+	return ok
+}
+
+func (ipq *indexedPriorityQueue[K, V]) Peek() (K, V, int64, bool) {
+	e := ipq.pq.Peek()
+	if e == nil {
+		var k K
+		var v V
+		return k, v, 0, false
+	}
+	return e.Value.key, e.Value.value, e.Priority(), true
+}
+
+func (ipq *indexedPriorityQueue[K, V]) Pop() (K, V, int64, bool) {
+	e := ipq.pq.Pop()
+	if e == nil {
+		var k K
+		var v V
+		return k, v, 0, false
+	}
+
+	delete(ipq.index, e.Value.key)
+	return e.Value.key, e.Value.value, e.Priority(), true
+}
+
+func (ipq *indexedPriorityQueue[K, V]) Size() int {
+	return ipq.pq.Size()
+}
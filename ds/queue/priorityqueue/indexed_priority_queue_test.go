@@ -0,0 +1,121 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type indexedPriorityQueueTestSuite struct {
+	suite.Suite
+
+	pq IndexedPriorityQueue[string, int]
+}
+
+func (s *indexedPriorityQueueTestSuite) SetupTest() {
+	s.pq = NewIndexedPriorityQueue[string, int](0)
+}
+
+func (s *indexedPriorityQueueTestSuite) TestOperation() {
+	_, _, _, ok := s.pq.Peek()
+	s.False(ok)
+	_, _, _, ok = s.pq.Pop()
+	s.False(ok)
+
+	s.True(s.pq.AddOrUpdate("a", 1, 10))
+	s.True(s.pq.AddOrUpdate("b", 2, 20))
+	s.True(s.pq.AddOrUpdate("c", 3, 30))
+	s.False(s.pq.AddOrUpdate("c", 4, 5))
+	s.Equal(3, s.pq.Size())
+
+	v, p, ok := s.pq.Get("c")
+	s.True(ok)
+	s.Equal(4, v)
+	s.Equal(int64(5), p)
+	_, _, ok = s.pq.Get("d")
+	s.False(ok)
+	s.True(s.pq.Contains("a"))
+	s.False(s.pq.Contains("d"))
+
+	k, v, p, ok := s.pq.Peek()
+	s.True(ok)
+	s.Equal("c", k)
+	s.Equal(4, v)
+	s.Equal(int64(5), p)
+
+	v, ok = s.pq.RemoveKey("a")
+	s.True(ok)
+	s.Equal(1, v)
+	_, ok = s.pq.RemoveKey("a")
+	s.False(ok)
+	s.False(s.pq.Contains("a"))
+
+	k, v, p, ok = s.pq.Pop()
+	s.True(ok)
+	s.Equal("c", k)
+	s.Equal(4, v)
+	s.Equal(int64(5), p)
+	s.False(s.pq.Contains("c"))
+
+	k, _, _, ok = s.pq.Pop()
+	s.True(ok)
+	s.Equal("b", k)
+	s.Equal(0, s.pq.Size())
+}
+
+func (s *indexedPriorityQueueTestSuite) TestDijkstra() {
+	type edge struct {
+		to     string
+		weight int64
+	}
+	graph := map[string][]edge{
+		"a": {{to: "b", weight: 7}, {to: "c", weight: 9}, {to: "f", weight: 14}},
+		"b": {{to: "c", weight: 10}, {to: "d", weight: 15}},
+		"c": {{to: "d", weight: 11}, {to: "f", weight: 2}},
+		"d": {{to: "e", weight: 6}},
+		"f": {{to: "e", weight: 9}},
+	}
+
+	dist := map[string]int64{}
+	s.pq.AddOrUpdate("a", 0, 0)
+	for s.pq.Size() > 0 {
+		k, _, d, _ := s.pq.Pop()
+		dist[k] = d
+		for _, e := range graph[k] {
+			if _, ok := dist[e.to]; ok {
+				continue
+			}
+			if _, cur, ok := s.pq.Get(e.to); !ok || d+e.weight < cur {
+				s.pq.AddOrUpdate(e.to, 0, d+e.weight)
+			}
+		}
+	}
+	s.Equal(map[string]int64{"a": 0, "b": 7, "c": 9, "d": 20, "e": 20, "f": 11}, dist)
+}
+
+func TestIndexedPriorityQueueTestSuite(t *testing.T) {
+	s := &indexedPriorityQueueTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,209 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+// pairingNode is the node of element in the pairing heap, the children of node is linked by
+// the next and prev, and the prev of the first child is the parent.
+type pairingNode struct {
+	e     *Element
+	child *pairingNode
+	next  *pairingNode
+	prev  *pairingNode
+}
+
+// pairingHeap is the backend by pairing heap, the root is the lowest element. The links is kept
+// in the pairingNode, and the index of element is the position of its node in the nodes.
+type pairingHeap struct {
+	pq   *priorityQueue
+	root *pairingNode
+	n    int
+
+	// nodes is the node of elements, the released position is nil and been kept in the free
+	nodes []*pairingNode
+	free  []int
+
+	// buf is reused by the merge of children
+	buf []*pairingNode
+}
+
+// meld links the two heap and return the new root
+func (h *pairingHeap) meld(a *pairingNode, b *pairingNode) *pairingNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if h.pq.lessElem(b.e, a.e) {
+		a, b = b, a
+	}
+
+	// b become the first child of a
+	b.prev = a
+	b.next = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	a.child = b
+	return a
+}
+
+// mergePairs merges the sibling list start from n by the two-pass pairing, and return the new root
+func (h *pairingHeap) mergePairs(n *pairingNode) *pairingNode {
+	h.buf = h.buf[:0]
+	for n != nil {
+		a, b := n, n.next
+		if b == nil {
+			n = nil
+		} else {
+			n = b.next
+			b.prev, b.next = nil, nil
+		}
+		a.prev, a.next = nil, nil
+		h.buf = append(h.buf, h.meld(a, b))
+	}
+
+	var root *pairingNode
+	for i := len(h.buf) - 1; i >= 0; i-- {
+		root = h.meld(h.buf[i], root)
+		h.buf[i] = nil
+	}
+	return root
+}
+
+// detach the subtree of n from its parent or siblings, n must not be the root
+func (h *pairingHeap) detach(n *pairingNode) {
+	if n.prev.child == n {
+		n.prev.child = n.next
+	} else {
+		n.prev.next = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	}
+	n.prev, n.next = nil, nil
+}
+
+// cut the node from the heap, the children of it is merged into the heap
+func (h *pairingHeap) cut(n *pairingNode) {
+	children := n.child
+	n.child = nil
+	if n == h.root {
+		h.root = h.mergePairs(children)
+		return
+	}
+
+	h.detach(n)
+	h.root = h.meld(h.root, h.mergePairs(children))
+}
+
+func (h *pairingHeap) push(e *Element) {
+	n := &pairingNode{e: e}
+	if len(h.free) > 0 {
+		e.index = h.free[len(h.free)-1]
+		h.free = h.free[:len(h.free)-1]
+		h.nodes[e.index] = n
+	} else {
+		e.index = len(h.nodes)
+		h.nodes = append(h.nodes, n)
+	}
+
+	h.n++
+	h.root = h.meld(h.root, n)
+}
+
+func (h *pairingHeap) peek() *Element {
+	if h.root == nil {
+		return nil
+	}
+	return h.root.e
+}
+
+func (h *pairingHeap) pop() *Element {
+	if h.root == nil {
+		return nil
+	}
+
+	e := h.root.e
+	h.remove(e)
+	return e
+}
+
+func (h *pairingHeap) remove(e *Element) {
+	h.cut(h.nodes[e.index])
+	h.nodes[e.index] = nil
+	h.free = append(h.free, e.index)
+	h.n--
+}
+
+func (h *pairingHeap) fix(e *Element, old int64) {
+	n := h.nodes[e.index]
+	if n == h.root {
+		if h.pq.less == nil && e.priority < old {
+			// decrease the priority of root, it's still the root
+			return
+		}
+		h.cut(n)
+		h.root = h.meld(h.root, n)
+		return
+	}
+
+	if h.pq.less != nil || e.priority > old {
+		// the children maybe lower than e now, merge them into the heap
+		children := n.child
+		n.child = nil
+		h.root = h.meld(h.root, h.mergePairs(children))
+	}
+	// the subtree of n is still valid
+	h.detach(n)
+	h.root = h.meld(h.root, n)
+}
+
+func (h *pairingHeap) size() int {
+	return h.n
+}
+
+func (h *pairingHeap) pushAll(es []*Element) {
+	for _, e := range es {
+		h.push(e)
+	}
+}
+
+func (h *pairingHeap) each(f func(e *Element) bool) {
+	for _, n := range h.nodes {
+		if n != nil && !f(n.e) {
+			return
+		}
+	}
+}
+
+func (h *pairingHeap) reset() {
+	for i := range h.nodes {
+		// set node to nil for GC
+		h.nodes[i] = nil
+	}
+	h.nodes = h.nodes[:0]
+	h.free = h.free[:0]
+	h.root = nil
+	h.n = 0
+}
@@ -26,6 +26,7 @@ package priorityqueue
 import (
 	"container/heap"
 	"fmt"
+	"sort"
 )
 
 // Element for priority queue element
@@ -41,6 +42,9 @@ type Element struct {
 
 	// pq is the refer of priority queue
 	pq *priorityQueue
+
+	// seq is the sequence of element added or updated, it's only assigned by the stable PriorityQueue
+	seq uint64
 }
 
 // Priority return the priority value of element
@@ -48,7 +52,9 @@ func (e *Element) Priority() int64 {
 	return e.priority
 }
 
-// Index return the element index in slice
+// Index return the element index in slice, the index of lowest element is 0 except the pairing and
+// radix heap. The index of pairing heap is the position of its node, and the index of radix heap is
+// the position in its bucket. The index of NewSkipListPriorityQueue element is always 0.
 func (e *Element) Index() int {
 	return e.index
 }
@@ -77,6 +83,20 @@ type PriorityQueue interface {
 
 	// Size return the element size of queue
 	Size() int
+
+	// PopN return at most k lowest priority elements by order and remove them
+	PopN(k int) []*Element
+
+	// Merge moves all the elements of other into the queue, the other will been empty after
+	// merged. The element returned by other is still valid and belongs to the queue now.
+	Merge(other PriorityQueue) error
+
+	// Range calls f for each element in the queue by unspecified order until f return false,
+	// the queue must not been modified in f.
+	Range(f func(e *Element) bool)
+
+	// Clear removes all the elements from the queue
+	Clear()
 }
 
 // elems is an slice of Elements, it implement the heap.Interface
@@ -94,7 +114,7 @@ func (h *heapi) Len() int {
 
 // Less return the compare of slice element i and j, implement heap.Less
 func (h *heapi) Less(i int, j int) bool {
-	return h.pq.e[i].priority < h.pq.e[j].priority
+	return h.pq.lessElem(h.pq.e[i], h.pq.e[j])
 }
 
 // Swap change the slice element i and j, implement heap.Swap
@@ -123,52 +143,312 @@ func (h *heapi) Pop() interface{} {
 	return x
 }
 
+// backend is the heap implementation of priorityQueue, the element passed to it
+// is always in the queue.
+type backend interface {
+	push(e *Element)
+	peek() *Element
+	pop() *Element
+	remove(e *Element)
+
+	// fix the position of element after the priority changed from old
+	fix(e *Element, old int64)
+	size() int
+
+	// pushAll push the elements by batch, it's cheaper than push one by one
+	pushAll(es []*Element)
+
+	// each calls f for each element until f return false
+	each(f func(e *Element) bool)
+
+	// reset removes all the elements, the elements is not modified except the links of backend
+	reset()
+}
+
+// binaryHeap is the backend by container/heap
+type binaryHeap struct {
+	pq *priorityQueue
+}
+
+func (b binaryHeap) push(e *Element) {
+	e.index = len(b.pq.e)
+	heap.Push(b.pq.h, e)
+}
+
+func (b binaryHeap) peek() *Element {
+	if len(b.pq.e) == 0 {
+		return nil
+	}
+	return b.pq.e[0]
+}
+
+func (b binaryHeap) pop() *Element {
+	if len(b.pq.e) == 0 {
+		return nil
+	}
+	return heap.Pop(b.pq.h).(*Element)
+}
+
+func (b binaryHeap) remove(e *Element) {
+	heap.Remove(b.pq.h, e.index)
+}
+
+func (b binaryHeap) fix(e *Element, old int64) {
+	heap.Fix(b.pq.h, e.index)
+}
+
+func (b binaryHeap) size() int {
+	return len(b.pq.e)
+}
+
+func (b binaryHeap) pushAll(es []*Element) {
+	if !heapifiable(len(b.pq.e), len(es)) {
+		for _, e := range es {
+			b.push(e)
+		}
+		return
+	}
+
+	appendElems(b.pq, es)
+	heap.Init(b.pq.h)
+}
+
+func (b binaryHeap) each(f func(e *Element) bool) {
+	eachElems(b.pq, f)
+}
+
+func (b binaryHeap) reset() {
+	resetElems(b.pq)
+}
+
+// heapifiable reports whether the heapify of n+m elements is cheaper than push m elements one by one
+func heapifiable(n int, m int) bool {
+	return m > 0 && m >= n
+}
+
+// appendElems append the elements to the slice of array backend
+func appendElems(pq *priorityQueue, es []*Element) {
+	for _, e := range es {
+		e.index = len(pq.e)
+		pq.e = append(pq.e, e)
+	}
+}
+
+// eachElems calls f for each element of array backend until f return false
+func eachElems(pq *priorityQueue, f func(e *Element) bool) {
+	for _, e := range pq.e {
+		if !f(e) {
+			return
+		}
+	}
+}
+
+// resetElems removes all the elements of array backend
+func resetElems(pq *priorityQueue) {
+	for i := range pq.e {
+		// set element to nil for GC
+		pq.e[i] = nil
+	}
+	pq.e = pq.e[:0]
+}
+
+// Backend is the heap implementation of PriorityQueue
+type Backend int
+
+const (
+	// BackendBinary is the binary heap, it's the default Backend
+	BackendBinary Backend = iota
+
+	// BackendDAry is the d-ary heap, the arity is set by WithArity, it's more cache friendly
+	// than the binary heap with the larger arity.
+	BackendDAry
+
+	// BackendPairing is the pairing heap, it's cheap to decrease the priority by Update.
+	BackendPairing
+
+	// BackendRadix is the radix heap for the monotone priorities, the priority of added element
+	// should not be less than the last popped one. It only compares the priority ascending, the
+	// Less option is ignored.
+	BackendRadix
+)
+
 // priorityQueue is a implement by min heap, the 0th element is the lowest value
 type priorityQueue struct {
 	e elems
 	h *heapi
+
+	// b is the heap implementation, the e and h is only used by the binary and d-ary heap
+	b     backend
+	array bool
+
+	// less is the custom comparator, the priority is compared if it's nil
+	less LessFunc
+
+	// stable means the equal elements is popped by the order of added, seq is the
+	// sequence of the last added element
+	stable bool
+	seq    uint64
+}
+
+// LessFunc reports whether the element a should been popped before b
+type LessFunc func(a *Element, b *Element) bool
+
+// option is the options of PriorityQueue
+type option struct {
+	// Less is the comparator of element, the default is compare the priority ascending
+	Less LessFunc
+
+	// Stable means the equal elements is popped by the order of added(FIFO)
+	Stable bool
+
+	// Backend is the heap implementation, the default is BackendBinary
+	Backend Backend
+
+	// Arity is the arity of BackendDAry, the default is 4
+	Arity int
+}
+
+// Option is the interface to set option
+type Option interface {
+	Apply(*option)
+}
+
+// optionFunc wraps a func so it satisfies the Option interface
+type optionFunc func(*option)
+
+func (f optionFunc) Apply(opt *option) {
+	f(opt)
+}
+
+// WithLess set the Less field, it can been used to construct the max-heap or compare the
+// composite keys, EX: func(a, b *Element) bool { return a.Priority() > b.Priority() }
+func WithLess(less LessFunc) Option {
+	return optionFunc(func(opt *option) {
+		opt.Less = less
+	})
+}
+
+// WithStable set the Stable field, the element been updated is treated as been added again.
+func WithStable() Option {
+	return optionFunc(func(opt *option) {
+		opt.Stable = true
+	})
+}
+
+// WithBackend set the Backend field
+func WithBackend(b Backend) Option {
+	return optionFunc(func(opt *option) {
+		opt.Backend = b
+	})
+}
+
+// WithArity set the Arity field, the value below 2 is treated as 4
+func WithArity(d int) Option {
+	return optionFunc(func(opt *option) {
+		opt.Arity = d
+	})
 }
 
 // NewPriorityQueue construct a PriorityQueue
 func NewPriorityQueue(size int) PriorityQueue {
+	return NewPriorityQueueWithOptions(size)
+}
+
+// NewPriorityQueueWithOptions construct a PriorityQueue with the options
+func NewPriorityQueueWithOptions(size int, opts ...Option) PriorityQueue {
+	options := option{}
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+
 	pq := &priorityQueue{
-		e: make(elems, 0, size),
+		e:      make(elems, 0, size),
+		less:   options.Less,
+		stable: options.Stable,
 	}
 	pq.h = &heapi{
 		pq: pq,
 	}
+
+	switch options.Backend {
+	case BackendDAry:
+		d := options.Arity
+		if d < 2 {
+			d = 4
+		}
+		pq.b = &dAryHeap{pq: pq, d: d}
+		pq.array = true
+	case BackendPairing:
+		pq.b = &pairingHeap{pq: pq}
+	case BackendRadix:
+		pq.less = nil
+		pq.b = &radixHeap{pq: pq}
+	default:
+		pq.b = binaryHeap{pq: pq}
+		pq.array = true
+	}
+	return pq
+}
+
+// NewFromSlice construct a PriorityQueue with the values, the priority of value is returned by
+// the priority func. The heap is built in O(n) for the BackendBinary and BackendDAry.
+func NewFromSlice(values []interface{}, priority func(v interface{}) int64, opts ...Option) PriorityQueue {
+	pq := NewPriorityQueueWithOptions(len(values), opts...).(*priorityQueue)
+	es := make([]*Element, 0, len(values))
+	for _, v := range values {
+		es = append(es, &Element{
+			Value:    v,
+			priority: priority(v),
+			pq:       pq,
+			seq:      pq.nextSeq(),
+		})
+	}
+	pq.b.pushAll(es)
 	return pq
 }
 
+// lessElem reports whether the element a should been popped before b
+func (pq *priorityQueue) lessElem(a *Element, b *Element) bool {
+	if pq.less == nil {
+		if a.priority != b.priority || !pq.stable {
+			return a.priority < b.priority
+		}
+		return a.seq < b.seq
+	}
+
+	if pq.less(a, b) {
+		return true
+	}
+	if !pq.stable || pq.less(b, a) {
+		return false
+	}
+	return a.seq < b.seq
+}
+
 // Add element to the PriorityQueue, it will return the element witch been added
 func (pq *priorityQueue) Add(x interface{}, priority int64) *Element {
 	e := &Element{
 		Value:    x,
 		priority: priority,
-		index:    len(pq.e),
 		pq:       pq,
+		seq:      pq.nextSeq(),
 	}
-	heap.Push(pq.h, e)
+	pq.b.push(e)
 	return e
 }
 
 // Peek return the lowest priority element
 func (pq *priorityQueue) Peek() *Element {
-	if len(pq.e) == 0 {
-		return nil
-	}
-
-	return pq.e[0]
+	return pq.b.peek()
 }
 
 // Pop return the lowest priority element and remove it
 func (pq *priorityQueue) Pop() *Element {
-	if len(pq.e) == 0 {
+	e := pq.b.pop()
+	if e == nil {
 		return nil
 	}
 
-	x := heap.Pop(pq.h)
-	e := x.(*Element)
 	e.index = -1
 	e.pq = nil
 	return e
@@ -180,14 +460,16 @@ func (pq *priorityQueue) Remove(e *Element) error {
 		return fmt.Errorf("PriorityQueue.Remove: QueueMatchFailed: Element[%v], Queue[%v]", e.pq, pq)
 	}
 
-	if e.index < 0 || e.index >= len(pq.e) {
-		return fmt.Errorf("PriorityQueue.Remove: OutOfIndex: Index[%v], Len[%v]", e.index, len(pq.e))
-	}
-	if e.priority != pq.e[e.index].priority {
-		return fmt.Errorf("PriorityQueue.Remove: PriorityMatchFailed: Element[%v], Queue[%v]", e.priority, pq.e[e.index].priority)
+	if pq.array {
+		if e.index < 0 || e.index >= len(pq.e) {
+			return fmt.Errorf("PriorityQueue.Remove: OutOfIndex: Index[%v], Len[%v]", e.index, len(pq.e))
+		}
+		if e.priority != pq.e[e.index].priority {
+			return fmt.Errorf("PriorityQueue.Remove: PriorityMatchFailed: Element[%v], Queue[%v]", e.priority, pq.e[e.index].priority)
+		}
 	}
 
-	heap.Remove(pq.h, e.index)
+	pq.b.remove(e)
 	e.index = -1
 	e.pq = nil
 	return nil
@@ -198,7 +480,7 @@ func (pq *priorityQueue) Update(e *Element, priority int64) error {
 	if e.pq != pq {
 		return fmt.Errorf("PriorityQueue.Update: QueueMatchFailed: Element[%v], Queue[%v]", e.pq, pq)
 	}
-	if e.index < 0 || e.index >= len(pq.e) {
+	if pq.array && (e.index < 0 || e.index >= len(pq.e)) {
 		return fmt.Errorf("PriorityQueue.Update: OutOfIndex: Index[%v], Len[%v]", e.index, len(pq.e))
 	}
 	if e.priority == priority {
@@ -206,11 +488,119 @@ func (pq *priorityQueue) Update(e *Element, priority int64) error {
 		return nil
 	}
 
+	old := e.priority
 	e.priority = priority
-	heap.Fix(pq.h, e.index)
+	e.seq = pq.nextSeq()
+	pq.b.fix(e, old)
 	return nil
 }
 
+// nextSeq return the sequence for the element added or updated, it's always 0 if the queue isn't stable
+func (pq *priorityQueue) nextSeq() uint64 {
+	if !pq.stable {
+		return 0
+	}
+
+	pq.seq++
+	return pq.seq
+}
+
 func (pq *priorityQueue) Size() int {
-	return len(pq.e)
+	return pq.b.size()
+}
+
+// PopN return at most k lowest priority elements by order and remove them
+func (pq *priorityQueue) PopN(k int) []*Element {
+	if n := pq.b.size(); k > n {
+		k = n
+	}
+	if k <= 0 {
+		return nil
+	}
+
+	es := make([]*Element, 0, k)
+	for len(es) < k {
+		es = append(es, pq.Pop())
+	}
+	return es
+}
+
+// Merge moves all the elements of other into the queue, the other will been empty after
+// merged. The elements of other is treated as been added after the elements of queue.
+func (pq *priorityQueue) Merge(other PriorityQueue) error {
+	es, err := takeFrom(other, pq)
+	if err != nil {
+		return err
+	}
+
+	pq.absorb(es)
+	return nil
+}
+
+// takeFrom removes all the elements of other and return them, the other must not be the pq
+func takeFrom(other PriorityQueue, pq *priorityQueue) ([]*Element, error) {
+	switch o := other.(type) {
+	case *priorityQueue:
+		if o == pq {
+			return nil, fmt.Errorf("PriorityQueue.Merge: QueueMatchFailed: Other[%p], Queue[%p]", o, pq)
+		}
+		return o.take(), nil
+	case *concurrentPriorityQueue:
+		if o.pq == pq {
+			return nil, fmt.Errorf("PriorityQueue.Merge: QueueMatchFailed: Other[%p], Queue[%p]", o.pq, pq)
+		}
+
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return o.pq.take(), nil
+	case *skipList:
+		if o.pq == pq {
+			return nil, fmt.Errorf("PriorityQueue.Merge: QueueMatchFailed: Other[%p], Queue[%p]", o.pq, pq)
+		}
+		return o.take(), nil
+	}
+	return nil, fmt.Errorf("PriorityQueue.Merge: UnsupportedQueue: Other[%T]", other)
+}
+
+// take removes all the elements from the queue and return them, they're sorted by the added
+// order if the queue is stable
+func (pq *priorityQueue) take() []*Element {
+	es := make([]*Element, 0, pq.b.size())
+	pq.b.each(func(e *Element) bool {
+		es = append(es, e)
+		return true
+	})
+	pq.b.reset()
+
+	if pq.stable {
+		sort.Slice(es, func(i int, j int) bool {
+			return es[i].seq < es[j].seq
+		})
+	}
+	return es
+}
+
+// absorb push the elements taken from other queue
+func (pq *priorityQueue) absorb(es []*Element) {
+	for _, e := range es {
+		e.pq = pq
+		e.seq = pq.nextSeq()
+	}
+	pq.b.pushAll(es)
+}
+
+// Range calls f for each element in the queue by unspecified order until f return false,
+// the queue must not been modified in f.
+func (pq *priorityQueue) Range(f func(e *Element) bool) {
+	pq.b.each(f)
+}
+
+// Clear removes all the elements from the queue
+func (pq *priorityQueue) Clear() {
+	pq.b.each(func(e *Element) bool {
+		e.index = -1
+		e.pq = nil
+		return true
+	})
+	pq.b.reset()
 }
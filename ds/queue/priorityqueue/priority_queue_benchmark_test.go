@@ -0,0 +1,118 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"testing"
+)
+
+var benchmarkBackends = []struct {
+	name string
+	opts []Option
+}{
+	{name: "Binary", opts: []Option{WithBackend(BackendBinary)}},
+	{name: "DAry4", opts: []Option{WithBackend(BackendDAry), WithArity(4)}},
+	{name: "Pairing", opts: []Option{WithBackend(BackendPairing)}},
+	{name: "Radix", opts: []Option{WithBackend(BackendRadix)}},
+}
+
+// Benchmark_PriorityQueue_Monotone simulates the timer workload, the priority of added
+// element is always greater than the last popped one.
+func Benchmark_PriorityQueue_Monotone(b *testing.B) {
+	for _, bb := range benchmarkBackends {
+		b.Run(bb.name, func(b *testing.B) {
+			r := rand.New(rand.NewSource(1))
+			pq := NewPriorityQueueWithOptions(1024, bb.opts...)
+			for i := 0; i < 1024; i++ {
+				pq.Add(nil, r.Int63n(1000))
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e := pq.Pop()
+				pq.Add(nil, e.Priority()+r.Int63n(1000))
+			}
+		})
+	}
+}
+
+// Benchmark_PriorityQueue_DecreaseKey simulates the Dijkstra workload, the priority of element
+// is decreased before popped.
+func Benchmark_PriorityQueue_DecreaseKey(b *testing.B) {
+	for _, bb := range benchmarkBackends {
+		b.Run(bb.name, func(b *testing.B) {
+			r := rand.New(rand.NewSource(1))
+			pq := NewPriorityQueueWithOptions(1024, bb.opts...)
+			es := make([]*Element, 0, 1024)
+			for i := 0; i < 1024; i++ {
+				es = append(es, pq.Add(i, 1<<40+r.Int63n(1<<20)))
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				e := es[r.Intn(len(es))]
+				_ = pq.Update(e, e.Priority()-r.Int63n(1<<10)-1)
+				if i%8 == 0 {
+					p := pq.Pop()
+					es[p.Value.(int)] = pq.Add(p.Value, 1<<40+r.Int63n(1<<20))
+				}
+			}
+		})
+	}
+}
+
+// Benchmark_ConcurrentPriorityQueue_Parallel simulates the timer workload from many goroutines,
+// most of the added elements is canceled before popped.
+func Benchmark_ConcurrentPriorityQueue_Parallel(b *testing.B) {
+	queues := []struct {
+		name string
+		new  func() ConcurrentPriorityQueue
+	}{
+		{name: "Mutex", new: func() ConcurrentPriorityQueue { return NewConcurrentPriorityQueue(1024) }},
+		{name: "SkipList", new: NewSkipListPriorityQueue},
+	}
+	for _, bb := range queues {
+		b.Run(bb.name, func(b *testing.B) {
+			pq := bb.new()
+			for i := 0; i < 1024; i++ {
+				pq.Add(nil, int64(i)<<10)
+			}
+
+			var seed int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				r := rand.New(rand.NewSource(atomic.AddInt64(&seed, 1)))
+				for i := 0; pb.Next(); i++ {
+					e := pq.Add(nil, r.Int63n(1<<20))
+					if i%8 == 0 {
+						pq.Pop()
+						continue
+					}
+					_ = pq.Remove(e)
+				}
+			})
+		})
+	}
+}
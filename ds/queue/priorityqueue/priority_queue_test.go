@@ -525,6 +525,67 @@ func (s *priorityQueueTestSuite) initPriorityQueue() {
 	}
 }
 
+func (s *priorityQueueTestSuite) pop(pq PriorityQueue) []interface{} {
+	vs := make([]interface{}, 0, pq.Size())
+	for e := pq.Pop(); e != nil; e = pq.Pop() {
+		vs = append(vs, e.Value)
+	}
+	return vs
+}
+
+func (s *priorityQueueTestSuite) TestWithLess() {
+	// max-heap
+	pq := NewPriorityQueueWithOptions(0, WithLess(func(a *Element, b *Element) bool {
+		return a.Priority() > b.Priority()
+	}))
+	for i := 0; i < 5; i++ {
+		pq.Add(i, int64(i))
+	}
+	s.Equal([]interface{}{4, 3, 2, 1, 0}, s.pop(pq))
+
+	// composite key, the priority ascending and then the value descending
+	pq = NewPriorityQueueWithOptions(0, WithLess(func(a *Element, b *Element) bool {
+		if a.Priority() != b.Priority() {
+			return a.Priority() < b.Priority()
+		}
+		return a.Value.(int) > b.Value.(int)
+	}))
+	pq.Add(1, 1)
+	pq.Add(2, 1)
+	pq.Add(3, 0)
+	pq.Add(4, 1)
+	s.Equal([]interface{}{3, 4, 2, 1}, s.pop(pq))
+}
+
+func (s *priorityQueueTestSuite) TestStable() {
+	for _, opts := range [][]Option{
+		{WithStable()},
+		{WithStable(), WithLess(func(a *Element, b *Element) bool {
+			return a.Priority() < b.Priority()
+		})},
+	} {
+		pq := NewPriorityQueueWithOptions(0, opts...)
+		es := make([]*Element, 0, 20)
+		for i := 0; i < 20; i++ {
+			es = append(es, pq.Add(i, int64(i%2)))
+		}
+
+		// the updated element is treated as added again
+		s.NoError(pq.Update(es[0], 2))
+		s.NoError(pq.Update(es[0], 1))
+
+		expect := []interface{}{}
+		for i := 2; i < 20; i += 2 {
+			expect = append(expect, i)
+		}
+		for i := 1; i < 20; i += 2 {
+			expect = append(expect, i)
+		}
+		expect = append(expect, 0)
+		s.Equal(expect, s.pop(pq))
+	}
+}
+
 func TestPriorityQueueTestSuite(t *testing.T) {
 	s := &priorityQueueTestSuite{}
 	suite.Run(t, s)
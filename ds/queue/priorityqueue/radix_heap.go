@@ -0,0 +1,189 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"math/bits"
+)
+
+// radixHeap is the backend by radix heap, the element is put into the bucket by the highest
+// bit differ from the last popped priority. The elements in the lower bucket is always less
+// than the elements in the higher bucket, so the Pop only redistributes the lowest bucket.
+type radixHeap struct {
+	pq      *priorityQueue
+	buckets [65][]*Element
+	n       int
+
+	// last is the last popped priority mapped by key
+	last uint64
+
+	// below is the count of element in bucket 0 whose priority is less than the last popped,
+	// they violate the monotone priority and the bucket 0 should been scanned to find the lowest
+	below int
+}
+
+// key maps the priority to uint64 with the same order
+func key(priority int64) uint64 {
+	return uint64(priority) ^ (1 << 63)
+}
+
+// bucket return the bucket index of the priority
+func (h *radixHeap) bucket(priority int64) int {
+	k := key(priority)
+	if k <= h.last {
+		return 0
+	}
+	return bits.Len64(k ^ h.last)
+}
+
+func (h *radixHeap) add(e *Element, b int) {
+	e.index = len(h.buckets[b])
+	h.buckets[b] = append(h.buckets[b], e)
+	if b == 0 && key(e.priority) < h.last {
+		h.below++
+	}
+}
+
+func (h *radixHeap) del(e *Element, b int) {
+	bucket := h.buckets[b]
+	n := len(bucket) - 1
+	bucket[e.index] = bucket[n]
+	bucket[e.index].index = e.index
+	bucket[n] = nil
+	h.buckets[b] = bucket[:n]
+	if b == 0 && key(e.priority) < h.last {
+		h.below--
+	}
+}
+
+// lowest return the lowest element in the bucket
+func (h *radixHeap) lowest(b int) *Element {
+	bucket := h.buckets[b]
+	if b == 0 && h.below == 0 && !h.pq.stable {
+		// all the elements in bucket 0 is equal
+		return bucket[len(bucket)-1]
+	}
+
+	min := bucket[0]
+	for _, e := range bucket[1:] {
+		if h.pq.lessElem(e, min) {
+			min = e
+		}
+	}
+	return min
+}
+
+// first return the index of first non-empty bucket, it returns -1 if all bucket is empty
+func (h *radixHeap) first() int {
+	for b := range h.buckets {
+		if len(h.buckets[b]) > 0 {
+			return b
+		}
+	}
+	return -1
+}
+
+func (h *radixHeap) push(e *Element) {
+	h.n++
+	h.add(e, h.bucket(e.priority))
+}
+
+func (h *radixHeap) peek() *Element {
+	b := h.first()
+	if b < 0 {
+		return nil
+	}
+	return h.lowest(b)
+}
+
+func (h *radixHeap) pop() *Element {
+	b := h.first()
+	if b < 0 {
+		return nil
+	}
+
+	if b > 0 {
+		// redistribute the bucket by the lowest priority, all the elements will
+		// been moved into the lower bucket
+		h.last = key(h.lowest(b).priority)
+		bucket := h.buckets[b]
+		h.buckets[b] = bucket[:0]
+		for i, e := range bucket {
+			bucket[i] = nil
+			h.add(e, h.bucket(e.priority))
+		}
+	}
+
+	e := h.lowest(0)
+	h.n--
+	h.del(e, 0)
+	return e
+}
+
+func (h *radixHeap) remove(e *Element) {
+	h.n--
+	h.del(e, h.bucket(e.priority))
+}
+
+func (h *radixHeap) fix(e *Element, old int64) {
+	// remove from the old bucket
+	p := e.priority
+	e.priority = old
+	h.del(e, h.bucket(old))
+	e.priority = p
+
+	h.add(e, h.bucket(p))
+}
+
+func (h *radixHeap) size() int {
+	return h.n
+}
+
+func (h *radixHeap) pushAll(es []*Element) {
+	for _, e := range es {
+		h.push(e)
+	}
+}
+
+func (h *radixHeap) each(f func(e *Element) bool) {
+	for _, bucket := range h.buckets {
+		for _, e := range bucket {
+			if !f(e) {
+				return
+			}
+		}
+	}
+}
+
+func (h *radixHeap) reset() {
+	for b, bucket := range h.buckets {
+		for i := range bucket {
+			// set element to nil for GC
+			bucket[i] = nil
+		}
+		h.buckets[b] = bucket[:0]
+	}
+	h.n = 0
+	h.last = 0
+	h.below = 0
+}
@@ -0,0 +1,70 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type radixHeapTestSuite struct {
+	suite.Suite
+}
+
+func (s *radixHeapTestSuite) pop(pq PriorityQueue) []int64 {
+	var ps []int64
+	for e := pq.Pop(); e != nil; e = pq.Pop() {
+		ps = append(ps, e.Priority())
+	}
+	return ps
+}
+
+func (s *radixHeapTestSuite) TestNegative() {
+	pq := NewPriorityQueueWithOptions(0, WithBackend(BackendRadix))
+	for _, p := range []int64{3, -1, math.MinInt64, 0, math.MaxInt64, -5} {
+		pq.Add(nil, p)
+	}
+	s.Equal([]int64{math.MinInt64, -5, -1, 0, 3, math.MaxInt64}, s.pop(pq))
+}
+
+func (s *radixHeapTestSuite) TestNotMonotone() {
+	pq := NewPriorityQueueWithOptions(0, WithBackend(BackendRadix))
+	pq.Add(nil, 10)
+	pq.Add(nil, 20)
+	s.Equal(int64(10), pq.Pop().Priority())
+
+	// the priority is less than the last popped
+	e := pq.Add(nil, 5)
+	pq.Add(nil, 8)
+	pq.Add(nil, 10)
+	s.Equal(int64(5), pq.Peek().Priority())
+	s.NoError(pq.Update(e, 9))
+	s.Equal([]int64{8, 9, 10, 20}, s.pop(pq))
+}
+
+func TestRadixHeapTestSuite(t *testing.T) {
+	s := &radixHeapTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,436 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// skipListMaxLevel is the max level of skipList, it's enough for 4^16 elements
+const skipListMaxLevel = 16
+
+// skipNode is the node of skipList, the priority and seq is the key copied from the element
+// when added, so the node can been compared without access the element.
+type skipNode struct {
+	e        *Element
+	priority int64
+	seq      uint64
+
+	// next is the *skipNode of every level, it's accessed by atomic
+	next []unsafe.Pointer
+
+	// mu is held when the node is the predecessor of the node been added or removed,
+	// and when the node is removed
+	mu sync.Mutex
+
+	// marked means the node is logically removed, linked means the node is linked in all levels
+	marked uint32
+	linked uint32
+}
+
+func (n *skipNode) loadNext(l int) *skipNode {
+	return (*skipNode)(atomic.LoadPointer(&n.next[l]))
+}
+
+func (n *skipNode) storeNext(l int, next *skipNode) {
+	atomic.StorePointer(&n.next[l], unsafe.Pointer(next))
+}
+
+func (n *skipNode) isMarked() bool {
+	return atomic.LoadUint32(&n.marked) == 1
+}
+
+// isVisible reports whether the node is linked and not removed
+func (n *skipNode) isVisible() bool {
+	return atomic.LoadUint32(&n.linked) == 1 && !n.isMarked()
+}
+
+// before reports whether the node should been popped before the key
+func (n *skipNode) before(priority int64, seq uint64) bool {
+	return n.priority < priority || (n.priority == priority && n.seq < seq)
+}
+
+// skipLevel returns the level of node with the seq, the count of node in every level is 1/4 of
+// the level below. The level is calculated from the hash of seq, so it doesn't need the locked rand.
+func skipLevel(seq uint64) int {
+	// the splitmix64 of seq
+	x := seq + 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x ^= x >> 31
+
+	l := 1 + bits.TrailingZeros64(x)/2
+	if l > skipListMaxLevel {
+		l = skipListMaxLevel
+	}
+	return l
+}
+
+// skipNodes is the predecessors or successors of every level
+type skipNodes = [skipListMaxLevel]*skipNode
+
+// lockPreds locks the distinct predecessors of the level below top from the bottom, it returns false
+// and unlocks them if the predecessors or successors has been changed. The victim is the successor
+// been removed, it's nil when add.
+func lockPreds(preds *skipNodes, succs *skipNodes, top int, victim *skipNode) bool {
+	for l := 0; l < top; l++ {
+		pred, succ := preds[l], succs[l]
+		if l == 0 || pred != preds[l-1] {
+			pred.mu.Lock()
+		}
+
+		valid := !pred.isMarked() && pred.loadNext(l) == succ
+		if victim != nil {
+			valid = valid && succ == victim
+		} else {
+			valid = valid && (succ == nil || !succ.isMarked())
+		}
+		if !valid {
+			unlockPreds(preds, l+1)
+			return false
+		}
+	}
+	return true
+}
+
+// unlockPreds unlocks the distinct predecessors of the level below top
+func unlockPreds(preds *skipNodes, top int) {
+	for l := 0; l < top; l++ {
+		if l == 0 || preds[l] != preds[l-1] {
+			preds[l].mu.Unlock()
+		}
+	}
+}
+
+// skipList implement the ConcurrentPriorityQueue by the lazy concurrent skip list, the Add, Remove
+// and Update only lock the nodes around the position of element, and the Peek, Range and the search
+// of position doesn't lock. The Pop removes the first node in the bottom level witch isn't removed
+// by others, so the Pop concurrent with Add maybe miss the lower priority element been adding.
+type skipList struct {
+	head *skipNode
+
+	// pq is the identify of the elements belong to the skipList, it's never used as queue
+	pq *priorityQueue
+
+	// seq is the sequence of the last added element, size is the count of element, they're
+	// accessed by atomic
+	seq  uint64
+	size int64
+
+	// mu protect the notifyC, the notifyC is closed to wakeup the waiting PopWait when element added,
+	// and waiters is the count of waiting PopWait which is accessed by atomic
+	mu      sync.Mutex
+	notifyC chan struct{}
+	waiters int32
+}
+
+// NewSkipListPriorityQueue construct a ConcurrentPriorityQueue backed by the concurrent skip list,
+// it has lower contention than the NewConcurrentPriorityQueue when accessed by many goroutines.
+// The elements is popped by the priority ascending and the order of added(FIFO) for the equal ones,
+// the Update element is treated as been added again. The Index of element is always 0, and the
+// element still refer to the queue after been removed.
+func NewSkipListPriorityQueue() ConcurrentPriorityQueue {
+	return &skipList{
+		head: &skipNode{
+			next: make([]unsafe.Pointer, skipListMaxLevel),
+		},
+		pq:      &priorityQueue{},
+		notifyC: make(chan struct{}),
+	}
+}
+
+// find the predecessors and successors of the key in every level
+func (sl *skipList) find(priority int64, seq uint64, preds *skipNodes, succs *skipNodes) {
+	pred := sl.head
+	for l := skipListMaxLevel - 1; l >= 0; l-- {
+		curr := pred.loadNext(l)
+		for curr != nil && curr.before(priority, seq) {
+			pred = curr
+			curr = pred.loadNext(l)
+		}
+		preds[l], succs[l] = pred, curr
+	}
+}
+
+// lookup returns the node of element, or nil if the element isn't in the skipList
+func (sl *skipList) lookup(e *Element) *skipNode {
+	priority, seq := atomic.LoadInt64(&e.priority), atomic.LoadUint64(&e.seq)
+
+	pred := sl.head
+	for l := skipListMaxLevel - 1; l >= 0; l-- {
+		curr := pred.loadNext(l)
+		for curr != nil && curr.before(priority, seq) {
+			pred = curr
+			curr = pred.loadNext(l)
+		}
+
+		if curr != nil && curr.priority == priority && curr.seq == seq {
+			if curr.e != e || !curr.isVisible() {
+				return nil
+			}
+			return curr
+		}
+	}
+	return nil
+}
+
+// insert the element with the key into the skipList
+func (sl *skipList) insert(e *Element, priority int64, seq uint64) {
+	n := &skipNode{
+		e:        e,
+		priority: priority,
+		seq:      seq,
+		next:     make([]unsafe.Pointer, skipLevel(seq)),
+	}
+
+	var preds, succs skipNodes
+	for {
+		sl.find(priority, seq, &preds, &succs)
+		if lockPreds(&preds, &succs, len(n.next), nil) {
+			break
+		}
+	}
+
+	for l := range n.next {
+		n.storeNext(l, succs[l])
+	}
+	for l := range n.next {
+		preds[l].storeNext(l, n)
+	}
+	atomic.StoreUint32(&n.linked, 1)
+	unlockPreds(&preds, len(n.next))
+
+	atomic.AddInt64(&sl.size, 1)
+	sl.notify()
+}
+
+// remove the node from the skipList, it returns false if the node isn't visible
+func (sl *skipList) remove(n *skipNode) bool {
+	if atomic.LoadUint32(&n.linked) == 0 {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.isMarked() {
+		return false
+	}
+	atomic.StoreUint32(&n.marked, 1)
+	atomic.AddInt64(&sl.size, -1)
+
+	var preds, succs skipNodes
+	for {
+		sl.find(n.priority, n.seq, &preds, &succs)
+		if lockPreds(&preds, &succs, len(n.next), n) {
+			break
+		}
+	}
+
+	for l := len(n.next) - 1; l >= 0; l-- {
+		preds[l].storeNext(l, n.loadNext(l))
+	}
+	unlockPreds(&preds, len(n.next))
+	return true
+}
+
+// notify wakeup the waiting PopWait
+func (sl *skipList) notify() {
+	if atomic.LoadInt32(&sl.waiters) == 0 {
+		return
+	}
+
+	sl.mu.Lock()
+	defer sl.mu.Unlock()
+
+	close(sl.notifyC)
+	sl.notifyC = make(chan struct{})
+}
+
+// Add element to the PriorityQueue, it will return the element witch been added
+func (sl *skipList) Add(x interface{}, priority int64) *Element {
+	e := &Element{
+		Value:    x,
+		priority: priority,
+		pq:       sl.pq,
+		seq:      atomic.AddUint64(&sl.seq, 1),
+	}
+	sl.insert(e, e.priority, e.seq)
+	return e
+}
+
+// Peek return the lowest priority element
+func (sl *skipList) Peek() *Element {
+	for n := sl.head.loadNext(0); n != nil; n = n.loadNext(0) {
+		if n.isVisible() {
+			return n.e
+		}
+	}
+	return nil
+}
+
+// Pop return the lowest priority element and remove it
+func (sl *skipList) Pop() *Element {
+	for n := sl.head.loadNext(0); n != nil; n = n.loadNext(0) {
+		if sl.remove(n) {
+			return n.e
+		}
+	}
+	return nil
+}
+
+// PopWait return the lowest priority element and remove it, it will wait until there is
+// element in the queue or the ctx is done.
+func (sl *skipList) PopWait(ctx context.Context) (*Element, error) {
+	for {
+		if e := sl.Pop(); e != nil {
+			return e, nil
+		}
+
+		sl.mu.Lock()
+		atomic.AddInt32(&sl.waiters, 1)
+		notifyC := sl.notifyC
+		sl.mu.Unlock()
+
+		// the element maybe added before the waiters increased, so we pop it again
+		var err error
+		e := sl.Pop()
+		if e == nil {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-notifyC:
+			}
+		}
+
+		atomic.AddInt32(&sl.waiters, -1)
+		if e != nil {
+			return e, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Remove will remove the element from the priority queue
+func (sl *skipList) Remove(e *Element) error {
+	if e.pq != sl.pq {
+		return fmt.Errorf("PriorityQueue.Remove: QueueMatchFailed: Element[%v], Queue[%v]", e.pq, sl.pq)
+	}
+
+	n := sl.lookup(e)
+	if n == nil || !sl.remove(n) {
+		return fmt.Errorf("PriorityQueue.Remove: ElementNotFound: Element[%p]", e)
+	}
+	return nil
+}
+
+// Update the element in the priority queue with the new priority
+func (sl *skipList) Update(e *Element, priority int64) error {
+	if e.pq != sl.pq {
+		return fmt.Errorf("PriorityQueue.Update: QueueMatchFailed: Element[%v], Queue[%v]", e.pq, sl.pq)
+	}
+
+	n := sl.lookup(e)
+	if n == nil {
+		return fmt.Errorf("PriorityQueue.Update: ElementNotFound: Element[%p]", e)
+	}
+	if n.priority == priority {
+		// the priority doesn't change, just return nil as updated
+		return nil
+	}
+	if !sl.remove(n) {
+		return fmt.Errorf("PriorityQueue.Update: ElementNotFound: Element[%p]", e)
+	}
+
+	seq := atomic.AddUint64(&sl.seq, 1)
+	atomic.StoreInt64(&e.priority, priority)
+	atomic.StoreUint64(&e.seq, seq)
+	sl.insert(e, priority, seq)
+	return nil
+}
+
+// Size return the element size of queue
+func (sl *skipList) Size() int {
+	return int(atomic.LoadInt64(&sl.size))
+}
+
+// PopN return at most k lowest priority elements by order and remove them
+func (sl *skipList) PopN(k int) []*Element {
+	var es []*Element
+	for len(es) < k {
+		e := sl.Pop()
+		if e == nil {
+			break
+		}
+		es = append(es, e)
+	}
+	return es
+}
+
+// take removes all the elements from the queue and return them by order
+func (sl *skipList) take() []*Element {
+	return sl.PopN(sl.Size())
+}
+
+// Merge moves all the elements of other into the queue, the other will been empty after merged.
+func (sl *skipList) Merge(other PriorityQueue) error {
+	if other == PriorityQueue(sl) {
+		return fmt.Errorf("PriorityQueue.Merge: QueueMatchFailed: Other[%p], Queue[%p]", other, sl)
+	}
+
+	es, err := takeFrom(other, sl.pq)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range es {
+		seq := atomic.AddUint64(&sl.seq, 1)
+		e.pq = sl.pq
+		e.seq = seq
+		sl.insert(e, e.priority, seq)
+	}
+	return nil
+}
+
+// Range calls f for each element in the queue by order until f return false, the queue isn't
+// locked during the Range, so the element been added or removed concurrently maybe skipped.
+func (sl *skipList) Range(f func(e *Element) bool) {
+	for n := sl.head.loadNext(0); n != nil; n = n.loadNext(0) {
+		if n.isVisible() && !f(n.e) {
+			return
+		}
+	}
+}
+
+// Clear removes all the elements from the queue
+func (sl *skipList) Clear() {
+	for sl.Pop() != nil {
+		// pop until the queue is empty
+	}
+}
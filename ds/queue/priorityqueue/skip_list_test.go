@@ -0,0 +1,152 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type skipListTestSuite struct {
+	concurrentPriorityQueueTestSuite
+}
+
+func (s *skipListTestSuite) TestStable() {
+	for i := 0; i < 10; i++ {
+		s.pq.Add(i, int64(i%2))
+	}
+	e := s.pq.Add(10, 1)
+	s.NoError(s.pq.Update(e, 0))
+	s.Error(s.pq.Remove(NewPriorityQueue(0).Add(0, 0)))
+
+	var vs []interface{}
+	s.pq.Range(func(e *Element) bool {
+		vs = append(vs, e.Value)
+		return true
+	})
+	s.Equal([]interface{}{0, 2, 4, 6, 8, 10, 1, 3, 5, 7, 9}, vs)
+
+	vs = vs[:0]
+	for _, e := range s.pq.PopN(20) {
+		vs = append(vs, e.Value)
+	}
+	s.Equal([]interface{}{0, 2, 4, 6, 8, 10, 1, 3, 5, 7, 9}, vs)
+	s.Nil(s.pq.Peek())
+}
+
+func (s *skipListTestSuite) TestLevel() {
+	counts := make([]int, skipListMaxLevel+1)
+	for i := uint64(1); i <= 1<<16; i++ {
+		l := skipLevel(i)
+		s.GreaterOrEqual(l, 1)
+		s.LessOrEqual(l, skipListMaxLevel)
+		counts[l]++
+	}
+
+	// every level is about 1/4 of the level below
+	s.InDelta(3*(1<<16)/4, counts[1], 1<<10)
+	s.InDelta(3*(1<<16)/16, counts[2], 1<<9)
+}
+
+func (s *skipListTestSuite) TestConcurrentUpdate() {
+	const (
+		workers = 4
+		count   = 1000
+	)
+
+	es := make([]*Element, 0, workers*count)
+	for i := 0; i < workers*count; i++ {
+		es = append(es, s.pq.Add(i, int64(i)))
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		removed = map[interface{}]bool{}
+
+		// failed is the elements failed to update or remove, they must been popped by others
+		failed []*Element
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			r := rand.New(rand.NewSource(int64(i)))
+			for j := 0; j < count; j++ {
+				e := es[i*count+j]
+				switch r.Intn(3) {
+				case 0:
+					if err := s.pq.Update(e, r.Int63n(workers*count)); err != nil {
+						mu.Lock()
+						failed = append(failed, e)
+						mu.Unlock()
+					}
+				case 1:
+					err := s.pq.Remove(e)
+					mu.Lock()
+					if err != nil {
+						failed = append(failed, e)
+					} else {
+						removed[e.Value] = true
+					}
+					mu.Unlock()
+				default:
+					if p := s.pq.Pop(); p != nil {
+						mu.Lock()
+						removed[p.Value] = true
+						mu.Unlock()
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, e := range failed {
+		s.True(removed[e.Value])
+	}
+
+	s.Equal(workers*count-len(removed), s.pq.Size())
+	var ps []int64
+	for e := s.pq.Pop(); e != nil; e = s.pq.Pop() {
+		s.False(removed[e.Value])
+		ps = append(ps, e.Priority())
+	}
+	s.Equal(workers*count-len(removed), len(ps))
+	s.True(sort.SliceIsSorted(ps, func(i int, j int) bool {
+		return ps[i] < ps[j]
+	}))
+}
+
+func TestSkipListTestSuite(t *testing.T) {
+	s := &skipListTestSuite{
+		concurrentPriorityQueueTestSuite{
+			newQueue: NewSkipListPriorityQueue,
+		},
+	}
+	suite.Run(t, s)
+}
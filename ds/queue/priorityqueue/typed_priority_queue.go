@@ -0,0 +1,120 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+// TypedElement is the element of TypedPriorityQueue
+type TypedElement[T any] struct {
+	// Value for element
+	Value T
+
+	e *Element
+}
+
+// Priority return the priority value of element
+func (te *TypedElement[T]) Priority() int64 {
+	return te.e.Priority()
+}
+
+// Index return the element index in slice
+func (te *TypedElement[T]) Index() int {
+	return te.e.Index()
+}
+
+// TypedPriorityQueue is the PriorityQueue with typed value, so the caller doesn't need
+// to assert the type of Element.Value.
+type TypedPriorityQueue[T any] interface {
+	// Add element to the PriorityQueue, it will return the element witch been added
+	Add(v T, priority int64) *TypedElement[T]
+
+	// Peek return the lowest priority element
+	Peek() *TypedElement[T]
+
+	// Pop return the lowest priority element and remove it
+	Pop() *TypedElement[T]
+
+	// Remove will remove the element from the priority queue
+	Remove(v *TypedElement[T]) error
+
+	// Update the element in the priority queue with the new priority
+	Update(v *TypedElement[T], priority int64) error
+
+	// Size return the element size of queue
+	Size() int
+}
+
+// typedPriorityQueue implement the TypedPriorityQueue by the PriorityQueue, the Value of Element
+// is the *TypedElement.
+type typedPriorityQueue[T any] struct {
+	pq PriorityQueue
+}
+
+// NewTypedPriorityQueue construct a TypedPriorityQueue, the less is the comparator of element
+// like WithLess, the priority is compared ascending if it's nil. The WithLess option is ignored.
+func NewTypedPriorityQueue[T any](size int, less func(a *TypedElement[T], b *TypedElement[T]) bool, opts ...Option) TypedPriorityQueue[T] {
+	var l LessFunc
+	if less != nil {
+		l = func(a *Element, b *Element) bool {
+			return less(a.Value.(*TypedElement[T]), b.Value.(*TypedElement[T]))
+		}
+	}
+
+	return &typedPriorityQueue[T]{
+		pq: NewPriorityQueueWithOptions(size, append(opts, WithLess(l))...),
+	}
+}
+
+// unwrap return the TypedElement of e, it returns nil if e is nil
+func unwrap[T any](e *Element) *TypedElement[T] {
+	if e == nil {
+		return nil
+	}
+	return e.Value.(*TypedElement[T])
+}
+
+func (tpq *typedPriorityQueue[T]) Add(v T, priority int64) *TypedElement[T] {
+	te := &TypedElement[T]{
+		Value: v,
+	}
+	te.e = tpq.pq.Add(te, priority)
+	return te
+}
+
+func (tpq *typedPriorityQueue[T]) Peek() *TypedElement[T] {
+	return unwrap[T](tpq.pq.Peek())
+}
+
+func (tpq *typedPriorityQueue[T]) Pop() *TypedElement[T] {
+	return unwrap[T](tpq.pq.Pop())
+}
+
+func (tpq *typedPriorityQueue[T]) Remove(te *TypedElement[T]) error {
+	return tpq.pq.Remove(te.e)
+}
+
+func (tpq *typedPriorityQueue[T]) Update(te *TypedElement[T], priority int64) error {
+	return tpq.pq.Update(te.e, priority)
+}
+
+func (tpq *typedPriorityQueue[T]) Size() int {
+	return tpq.pq.Size()
+}
@@ -0,0 +1,84 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package priorityqueue
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type typedPriorityQueueTestSuite struct {
+	suite.Suite
+}
+
+type task struct {
+	name   string
+	weight int
+}
+
+func (s *typedPriorityQueueTestSuite) TestOperation() {
+	pq := NewTypedPriorityQueue[string](0, nil)
+	s.Nil(pq.Peek())
+	s.Nil(pq.Pop())
+
+	a := pq.Add("a", 3)
+	b := pq.Add("b", 2)
+	pq.Add("c", 1)
+	s.Equal(3, pq.Size())
+	s.Equal("c", pq.Peek().Value)
+	s.Equal(int64(1), pq.Peek().Priority())
+	s.Equal(0, pq.Peek().Index())
+
+	s.NoError(pq.Update(a, 0))
+	s.NoError(pq.Remove(b))
+	s.Error(pq.Remove(b))
+
+	var vs []string
+	for e := pq.Pop(); e != nil; e = pq.Pop() {
+		vs = append(vs, e.Value)
+	}
+	s.Equal([]string{"a", "c"}, vs)
+}
+
+func (s *typedPriorityQueueTestSuite) TestLess() {
+	pq := NewTypedPriorityQueue(0, func(a *TypedElement[task], b *TypedElement[task]) bool {
+		return a.Value.weight > b.Value.weight
+	}, WithStable(), WithLess(nil))
+
+	pq.Add(task{name: "a", weight: 1}, 0)
+	pq.Add(task{name: "b", weight: 2}, 0)
+	pq.Add(task{name: "c", weight: 1}, 0)
+	pq.Add(task{name: "d", weight: 3}, 0)
+
+	var vs []string
+	for e := pq.Pop(); e != nil; e = pq.Pop() {
+		vs = append(vs, e.Value.name)
+	}
+	s.Equal([]string{"d", "b", "a", "c"}, vs)
+}
+
+func TestTypedPriorityQueueTestSuite(t *testing.T) {
+	s := &typedPriorityQueueTestSuite{}
+	suite.Run(t, s)
+}
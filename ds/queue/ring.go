@@ -0,0 +1,159 @@
+// Copyright (c) 2018 soren yang
+//
+// Licensed under the MIT License
+// you may not use this file except in complicance with the License.
+// You may obtain a copy of the License at
+//
+//     https://opensource.org/licenses/MIT
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue
+
+import (
+	"fmt"
+)
+
+// option is the options of CircularQueue and CircularDeque
+type option struct {
+	// Growable means the capacity is doubled when push to the full queue, the capacity is
+	// rounded up to the power of two and is not limited by the max capacity.
+	Growable bool
+
+	// Shrink means the capacity is halved when the length is less than a quarter of it, but
+	// not less than the initial capacity. It's only valid with Growable.
+	Shrink bool
+
+	// Overwrite means the oldest element is dropped when push to the full queue, the element
+	// at the other side is dropped for CircularDeque. It can't been used with Growable.
+	Overwrite bool
+}
+
+// Option is the interface to set option
+type Option interface {
+	Apply(*option)
+}
+
+// optionFunc wraps a func so it satisfies the Option interface
+type optionFunc func(*option)
+
+func (f optionFunc) Apply(opt *option) {
+	f(opt)
+}
+
+// WithGrowable set the Growable field
+func WithGrowable() Option {
+	return optionFunc(func(opt *option) {
+		opt.Growable = true
+	})
+}
+
+// WithShrink set the Growable and Shrink field
+func WithShrink() Option {
+	return optionFunc(func(opt *option) {
+		opt.Growable = true
+		opt.Shrink = true
+	})
+}
+
+// WithOverwrite set the Overwrite field
+func WithOverwrite() Option {
+	return optionFunc(func(opt *option) {
+		opt.Overwrite = true
+	})
+}
+
+// newOption apply the opts and check the cap within [min, max], the max is ignored if growable
+func newOption(cap int, min int, max int, opts []Option) (option, error) {
+	options := option{}
+	for _, opt := range opts {
+		opt.Apply(&options)
+	}
+
+	if options.Growable && options.Overwrite {
+		return options, fmt.Errorf("Growable and Overwrite can't been used together")
+	}
+	if cap < min || (!options.Growable && cap > max) {
+		return options, fmt.Errorf("Cap should within [%d, %d]", min, max)
+	}
+	return options, nil
+}
+
+// ring is the ring buffer shared by the CircularQueue and CircularDeque, the elements is
+// stored in datas from start with size count, and end is the next position of the back.
+type ring struct {
+	datas []interface{}
+
+	start int
+	end   int
+	size  int
+
+	// options is the growable and overwrite options, min is the initial capacity
+	options option
+	min     int
+}
+
+// newRing construct the ring with the cap, it's rounded up to the power of two if growable
+func newRing(cap int, options option) ring {
+	if options.Growable {
+		n := 1
+		for n < cap {
+			n <<= 1
+		}
+		cap = n
+	}
+
+	return ring{
+		datas:   make([]interface{}, cap),
+		start:   0,
+		end:     0,
+		size:    0,
+		options: options,
+		min:     cap,
+	}
+}
+
+// makeRoom grow the full ring or drop an element by poll, it returns false if the ring
+// can't been pushed.
+func (r *ring) makeRoom(poll func() interface{}) bool {
+	switch {
+	case r.options.Growable:
+		r.resize(len(r.datas) * 2)
+	case r.options.Overwrite:
+		poll()
+	default:
+		return false
+	}
+	return true
+}
+
+// shrink halve the capacity if the length is less than a quarter of it
+func (r *ring) shrink() {
+	if r.options.Shrink && r.size <= len(r.datas)/4 && len(r.datas)/2 >= r.min {
+		r.resize(len(r.datas) / 2)
+	}
+}
+
+// resize change the capacity to n, n must not be less than the size
+func (r *ring) resize(n int) {
+	r.datas = copyRing(r.datas, r.start, r.size, n)
+	r.start = 0
+	r.end = r.size % n
+}
+
+// copyRing copy the size elements start from start to the new slice with n capacity,
+// the elements is placed at [0, size) of the new slice.
+func copyRing(datas []interface{}, start int, size int, n int) []interface{} {
+	ndatas := make([]interface{}, n)
+	if start+size <= len(datas) {
+		copy(ndatas, datas[start:start+size])
+	} else {
+		m := copy(ndatas, datas[start:])
+		copy(ndatas[m:], datas[:size-m])
+	}
+	return ndatas
+}
@@ -0,0 +1,221 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// Package wal implement the append-only log file, every entry is one json line.
+// It's shared by the DurableQueue of delayqueue and the FileStore of timingwheel.
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ErrClosed is the error when the Log is closed
+var ErrClosed = fmt.Errorf("log is closed")
+
+// Log is the append-only log file, every Append write one json line into the file and sync it.
+// It isn't goroutine-safe, the caller must protect it.
+type Log struct {
+	path string
+	f    *os.File
+
+	// n is the count of entry in the log file
+	n int
+}
+
+// Open replays the log file at path and opens it for append, the file will been created if it
+// doesn't exist. The fn is called with every entry, and the last entry is ignored if it's partial
+// written when crash. The live is called after replay to return the entries should been kept,
+// the log will been compacted with them if there is some entry been removed or updated.
+func Open(path string, fn func(data []byte) error, live func() []interface{}) (*Log, error) {
+	l := &Log{
+		path: path,
+	}
+
+	truncated, err := l.replay(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := live()
+	if truncated || l.n > len(entries) {
+		if err := l.compact(entries); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// replay the log file with fn, it returns whether the last entry is partial written.
+func (l *Log) replay(fn func(data []byte) error) (bool, error) {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		if !json.Valid(line) {
+			if i == len(lines)-1 {
+				// the last line maybe partial written when crash, ignore it
+				return true, nil
+			}
+			return false, fmt.Errorf("InvalidLogEntry: Line[%d]", i+1)
+		}
+		if err := fn(line); err != nil {
+			return false, fmt.Errorf("InvalidLogEntry: Line[%d], Err[%v]", i+1, err)
+		}
+		l.n++
+	}
+	return false, nil
+}
+
+// compact rewrite the log file with the entries, the new file is written into
+// an temporary file and then renamed.
+func (l *Log) compact(entries []interface{}) error {
+	f, err := os.CreateTemp(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// the temporary file is already renamed if success
+		_ = os.Remove(f.Name())
+	}()
+
+	w := bufio.NewWriter(f)
+	for _, e := range entries {
+		if err := write(w, e); err != nil {
+			_ = f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), l.path); err != nil {
+		return err
+	}
+	l.n = len(entries)
+	return nil
+}
+
+// open the log file for append
+func (l *Log) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	l.f = f
+	return nil
+}
+
+func write(w io.Writer, e interface{}) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Append write the entry into the log file and sync it
+func (l *Log) Append(e interface{}) error {
+	if l.f == nil {
+		return ErrClosed
+	}
+
+	if err := write(l.f, e); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+
+	l.n++
+	return nil
+}
+
+// Len returns the count of entry in the log file
+func (l *Log) Len() int {
+	return l.n
+}
+
+// Compactable returns true if the count of entry exceed the threshold and twice of the live count
+func (l *Log) Compactable(live int, threshold int) bool {
+	return l.n >= threshold && l.n >= 2*live
+}
+
+// Compact rewrite the log file with the entries and reopen it, the Log is closed if
+// failed to reopen.
+func (l *Log) Compact(entries []interface{}) error {
+	if l.f == nil {
+		return ErrClosed
+	}
+
+	if err := l.compact(entries); err != nil {
+		return err
+	}
+
+	// reopen the compacted file
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	l.f = nil
+	return l.open()
+}
+
+// Close the log file, it can't been used after closed
+func (l *Log) Close() error {
+	if l.f == nil {
+		return ErrClosed
+	}
+
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
@@ -0,0 +1,158 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package wal
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type entry struct {
+	Key   string `json:"key"`
+	Value int    `json:"value"`
+}
+
+type logTestSuite struct {
+	suite.Suite
+
+	path string
+
+	// entries is the replayed entries of the log
+	entries map[string]int
+}
+
+func (s *logTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "wal.log")
+	s.entries = make(map[string]int)
+}
+
+func (s *logTestSuite) open() (*Log, error) {
+	s.entries = make(map[string]int)
+	return Open(s.path, func(data []byte) error {
+		var e entry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+		s.entries[e.Key] = e.Value
+		return nil
+	}, s.live)
+}
+
+func (s *logTestSuite) live() []interface{} {
+	ret := make([]interface{}, 0, len(s.entries))
+	for k, v := range s.entries {
+		ret = append(ret, entry{Key: k, Value: v})
+	}
+	return ret
+}
+
+func (s *logTestSuite) lines() int {
+	data, err := os.ReadFile(s.path)
+	s.NoError(err)
+	return bytes.Count(data, []byte("\n"))
+}
+
+func (s *logTestSuite) TestAppendReplay() {
+	l, err := s.open()
+	s.NoError(err)
+	s.Equal(0, l.Len())
+
+	s.NoError(l.Append(entry{Key: "a", Value: 1}))
+	s.NoError(l.Append(entry{Key: "b", Value: 2}))
+	s.NoError(l.Append(entry{Key: "a", Value: 3}))
+	s.Equal(3, l.Len())
+	s.Equal(3, s.lines())
+	s.NoError(l.Close())
+	s.Equal(ErrClosed, l.Close())
+	s.Equal(ErrClosed, l.Append(entry{Key: "c"}))
+
+	// the updated entry is compacted when opened
+	l, err = s.open()
+	s.NoError(err)
+	defer l.Close()
+	s.Equal(map[string]int{"a": 3, "b": 2}, s.entries)
+	s.Equal(2, l.Len())
+	s.Equal(2, s.lines())
+}
+
+func (s *logTestSuite) TestPartialEntry() {
+	s.NoError(os.WriteFile(s.path, []byte(`{"key":"a","value":1}
+{"key":"b","val`), 0644))
+
+	l, err := s.open()
+	s.NoError(err)
+	defer l.Close()
+	s.Equal(map[string]int{"a": 1}, s.entries)
+	s.Equal(1, s.lines())
+
+	s.NoError(l.Append(entry{Key: "b", Value: 2}))
+	s.Equal(2, s.lines())
+}
+
+func (s *logTestSuite) TestInvalidEntry() {
+	s.NoError(os.WriteFile(s.path, []byte(`{"key":"a","val
+{"key":"b","value":2}
+`), 0644))
+	_, err := s.open()
+	s.Error(err)
+
+	s.NoError(os.WriteFile(s.path, []byte(`{"key":"a","value":"1"}
+`), 0644))
+	_, err = s.open()
+	s.Error(err)
+}
+
+func (s *logTestSuite) TestCompact() {
+	l, err := s.open()
+	s.NoError(err)
+	defer l.Close()
+
+	for i := 0; i < 4; i++ {
+		s.NoError(l.Append(entry{Key: "a", Value: i}))
+	}
+	s.False(l.Compactable(1, 5))
+	s.False(l.Compactable(3, 4))
+	s.True(l.Compactable(1, 4))
+
+	s.NoError(l.Compact([]interface{}{entry{Key: "a", Value: 3}}))
+	s.Equal(1, l.Len())
+	s.Equal(1, s.lines())
+
+	// the log is reopened after compacted
+	s.NoError(l.Append(entry{Key: "b", Value: 1}))
+	s.Equal(2, l.Len())
+	s.Equal(2, s.lines())
+
+	s.NoError(l.Close())
+	s.Equal(ErrClosed, l.Compact(nil))
+}
+
+func TestLogTestSuite(t *testing.T) {
+	s := &logTestSuite{}
+	suite.Run(t, s)
+}
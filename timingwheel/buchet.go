@@ -51,6 +51,14 @@ func (b *bucket) SetExpiration(v int64) bool {
 	return atomic.SwapInt64(&b.expiration, v) != v
 }
 
+// Volatile implement the delayqueue.Volatile, the bucket is kept in memory by the DurableQueue
+func (b *bucket) Volatile() {}
+
+// Len returns the count of timer in the bucket
+func (b *bucket) Len() int {
+	return b.timers.Len()
+}
+
 func (b *bucket) Add(t *timerTask) {
 	e := b.timers.PushBack(t)
 	t.b, t.e = b, e
@@ -0,0 +1,110 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"time"
+)
+
+// Timer is the channel-based timer like time.Timer, backed by the TimingWheel.
+// When the Timer expires, the current time will be sent on C.
+type Timer struct {
+	C <-chan time.Time
+
+	t TimerTask
+}
+
+// Stop prevents the Timer from firing, it returns true if the call stops the timer,
+// false if the timer has already expired or been stopped, or the TimingWheel is stopped.
+// Like time.Timer, Stop does not close the channel.
+func (t *Timer) Stop() bool {
+	stopped, err := t.t.Stop()
+	return err == nil && stopped
+}
+
+// Reset changes the timer to expire after duration d, it returns true if the timer had
+// been active, false if the timer had expired or been stopped, or the TimingWheel is stopped.
+// Like time.Timer, the channel should be drained before Reset if the timer has expired.
+func (t *Timer) Reset(d time.Duration) bool {
+	active, err := t.t.Reset(d)
+	return err == nil && active
+}
+
+// Ticker is the channel-based ticker like time.Ticker, backed by the TimingWheel.
+// The current time is sent on C at every tick, and the tick will been dropped if the
+// reader is slow.
+type Ticker struct {
+	C <-chan time.Time
+
+	t TimerTask
+}
+
+// Stop turns off the ticker, like time.Ticker, Stop does not close the channel.
+func (t *Ticker) Stop() {
+	_, _ = t.t.Stop()
+}
+
+// Reset stops the ticker and resets its period to the specified duration, the next tick
+// will arrive after the new period elapses. Like time.Ticker, it panics with the
+// ErrInvalidTickFuncDurationValue if the d is less than the tick of TimingWheel.
+func (t *Ticker) Reset(d time.Duration) {
+	if _, err := t.t.Reset(d); err == ErrInvalidTickFuncDurationValue {
+		panic(err)
+	}
+}
+
+// sendTime is the Handler which sends the time on c without blocking
+func sendTime(c chan time.Time) Handler {
+	return func(t time.Time) {
+		select {
+		case c <- t:
+		default:
+		}
+	}
+}
+
+func (tw *timingWheel) NewTimer(d time.Duration) (*Timer, error) {
+	c := make(chan time.Time, 1)
+	t, err := tw.AfterFunc(d, sendTime(c))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Timer{
+		C: c,
+		t: t,
+	}, nil
+}
+
+func (tw *timingWheel) NewTicker(d time.Duration, opts ...TickOption) (*Ticker, error) {
+	c := make(chan time.Time, 1)
+	t, err := tw.TickFunc(d, sendTime(c), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Ticker{
+		C: c,
+		t: t,
+	}, nil
+}
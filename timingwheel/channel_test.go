@@ -0,0 +1,149 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lsytj0413/ena/clock"
+	"github.com/stretchr/testify/suite"
+)
+
+type channelTestSuite struct {
+	suite.Suite
+
+	c  clock.FakeClock
+	tw TimingWheel
+}
+
+func (s *channelTestSuite) SetupTest() {
+	s.c = clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(s.c),
+	)
+	s.NoError(err)
+	s.tw = tw
+	s.tw.Start(context.Background())
+}
+
+func (s *channelTestSuite) TearDownTest() {
+	s.tw.Stop()
+}
+
+func (s *channelTestSuite) TestTimer() {
+	t, err := s.tw.NewTimer(20 * time.Millisecond)
+	s.NoError(err)
+
+	for i := 0; i < 2; i++ {
+		s.c.BlockUntil(1)
+		s.c.Advance(10 * time.Millisecond)
+	}
+	s.Equal(s.c.Now(), <-t.C)
+	s.False(t.Stop())
+
+	// reset the expired timer
+	s.False(t.Reset(10 * time.Millisecond))
+	s.c.BlockUntil(1)
+	s.c.Advance(10 * time.Millisecond)
+	s.Equal(s.c.Now(), <-t.C)
+}
+
+func (s *channelTestSuite) TestTimerStop() {
+	t, err := s.tw.NewTimer(10 * time.Millisecond)
+	s.NoError(err)
+	s.True(t.Stop())
+
+	s.c.BlockUntil(1)
+	s.c.Advance(10 * time.Millisecond)
+	s.Equal(uint64(1), s.tw.Stats().Stopped)
+	select {
+	case <-t.C:
+		s.FailNow("expect the stopped timer not fired")
+	default:
+	}
+
+	s.False(t.Reset(10 * time.Millisecond))
+	s.True(t.Reset(10 * time.Millisecond))
+}
+
+func (s *channelTestSuite) TestTicker() {
+	t, err := s.tw.NewTicker(10 * time.Millisecond)
+	s.NoError(err)
+
+	for i := 0; i < 3; i++ {
+		s.c.BlockUntil(1)
+		s.c.Advance(10 * time.Millisecond)
+		s.Equal(s.c.Now(), <-t.C)
+	}
+
+	// the tick will been dropped when the reader is slow
+	for i := 0; i < 2; i++ {
+		s.c.BlockUntil(1)
+		s.c.Advance(10 * time.Millisecond)
+	}
+	s.Equal(s.c.Now().Add(-10*time.Millisecond), <-t.C)
+
+	s.PanicsWithError(ErrInvalidTickFuncDurationValue.Error(), func() {
+		t.Reset(time.Millisecond)
+	})
+	t.Reset(20 * time.Millisecond)
+	s.c.BlockUntil(1)
+	s.c.Advance(10 * time.Millisecond)
+	select {
+	case <-t.C:
+		s.FailNow("expect the ticker not fired before new period")
+	default:
+	}
+	s.c.BlockUntil(1)
+	s.c.Advance(10 * time.Millisecond)
+	s.Equal(s.c.Now(), <-t.C)
+
+	t.Stop()
+	s.c.BlockUntil(1)
+	s.c.Advance(20 * time.Millisecond)
+	s.Equal(uint64(1), s.tw.Stats().Stopped)
+	select {
+	case <-t.C:
+		s.FailNow("expect the stopped ticker not fired")
+	default:
+	}
+}
+
+func (s *channelTestSuite) TestInvalid() {
+	_, err := s.tw.NewTicker(time.Millisecond)
+	s.Equal(ErrInvalidTickFuncDurationValue, err)
+
+	s.tw.Stop()
+	_, err = s.tw.NewTimer(time.Second)
+	s.Equal(ErrWheelStopped, err)
+}
+
+func TestChannelTestSuite(t *testing.T) {
+	s := &channelTestSuite{}
+	suite.Run(t, s)
+}
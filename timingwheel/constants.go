@@ -26,13 +26,73 @@ import "fmt"
 
 var (
 	// ErrInvalidTickValue is representation error of invalid tick value
-	ErrInvalidTickValue = fmt.Errorf("tick must be greater than or equal to 1ms")
+	ErrInvalidTickValue = fmt.Errorf("tick must be greater than or equal to the time unit")
+
+	// ErrInvalidTimeUnit is representation error of invalid time unit value
+	ErrInvalidTimeUnit = fmt.Errorf("time unit must greater than zero")
 
 	// ErrInvalidWheelSize is representation error of invalid wheel size value
 	ErrInvalidWheelSize = fmt.Errorf("wheel size must greater than zero")
 
 	// ErrInvalidTickFuncDurationValue is representation error of invalid tickfunc duration
 	ErrInvalidTickFuncDurationValue = fmt.Errorf("tickfunc duration must greater than or equal to timingwheel tick")
+
+	// ErrInvalidExecutor is representation error of nil executor
+	ErrInvalidExecutor = fmt.Errorf("executor must not be nil")
+
+	// ErrInvalidClock is representation error of nil clock
+	ErrInvalidClock = fmt.Errorf("clock must not be nil")
+
+	// ErrInvalidTickPolicy is representation error of unknown TickPolicy or MissedTickPolicy
+	ErrInvalidTickPolicy = fmt.Errorf("unknown tick policy")
+
+	// ErrInvalidJitter is representation error of negative jitter
+	ErrInvalidJitter = fmt.Errorf("jitter must greater than or equal to zero")
+
+	// ErrInvalidSchedule is representation error of the schedule never fire
+	ErrInvalidSchedule = fmt.Errorf("schedule must have next fire time")
+
+	// ErrInvalidShutdownMode is representation error of unknown shutdown mode
+	ErrInvalidShutdownMode = fmt.Errorf("unknown shutdown mode")
+
+	// ErrWheelStopped is representation error of the timingwheel has been stopped
+	ErrWheelStopped = fmt.Errorf("timingwheel has been stopped")
+
+	// ErrInvalidWorkers is representation error of invalid PoolExecutor workers count
+	ErrInvalidWorkers = fmt.Errorf("workers must greater than zero")
+
+	// ErrInvalidQueueSize is representation error of invalid PoolExecutor queue size
+	ErrInvalidQueueSize = fmt.Errorf("queue size must greater than or equal to zero")
+
+	// ErrInvalidOverflowPolicy is representation error of unknown PoolExecutor overflow policy
+	ErrInvalidOverflowPolicy = fmt.Errorf("unknown overflow policy")
+
+	// ErrInvalidShards is representation error of invalid ShardedTimingWheel wheels count
+	ErrInvalidShards = fmt.Errorf("shards must greater than zero and less than or equal to 256")
+
+	// ErrGroupStopped is representation error of the group has been stopped
+	ErrGroupStopped = fmt.Errorf("group has been stopped")
+
+	// ErrNoStore is representation error of persistent timer without Store
+	ErrNoStore = fmt.Errorf("store must been set by WithStore")
+
+	// ErrStoreClosed is representation error of the Store has been closed
+	ErrStoreClosed = fmt.Errorf("store has been closed")
+
+	// ErrInvalidPersistentHandler is representation error of Store without PersistentHandler
+	ErrInvalidPersistentHandler = fmt.Errorf("persistent handler must not be nil")
+
+	// ErrInvalidRecoverPolicy is representation error of unknown recover policy
+	ErrInvalidRecoverPolicy = fmt.Errorf("unknown recover policy")
+
+	// ErrInvalidDelayQueue is representation error of the DelayQueue shared by the ShardedTimingWheel
+	ErrInvalidDelayQueue = fmt.Errorf("delayqueue can't been shared by the sharded timingwheel")
+
+	// ErrInvalidKey is representation error of empty persistent timer key
+	ErrInvalidKey = fmt.Errorf("key must not be empty")
+
+	// ErrDuplicateKey is representation error of the persistent timer key already exists
+	ErrDuplicateKey = fmt.Errorf("key already exists")
 )
 
 // eventType is the representation of event, such as AddNew, RePost
@@ -44,6 +104,15 @@ var eventAddNew eventType = "AddNew"
 // eventDelete is the identify when timertask.Stop is called
 var eventDelete eventType = "Delete"
 
+// eventReset is the identify when timertask.Reset is called
+var eventReset eventType = "Reset"
+
+// eventStats is the identify when TimingWheel.Stats is called
+var eventStats eventType = "Stats"
+
+// eventStopGroup is the identify when Group.StopAll is called
+var eventStopGroup eventType = "StopGroup"
+
 // timerTaskType is the representation of timertask
 type timerTaskType = string
 
@@ -52,3 +121,32 @@ var taskAfter timerTaskType = "After"
 
 // taskTick is the identify when the timertask is repetitious
 var taskTick timerTaskType = "Tick"
+
+// taskSchedule is the identify when the timertask is repetitious by Schedule
+var taskSchedule timerTaskType = "Schedule"
+
+// ShutdownMode is the representation of how to deal with the pending timer when shutdown
+type ShutdownMode int
+
+const (
+	// ShutdownDrop will drop all the pending timer
+	ShutdownDrop ShutdownMode = iota
+
+	// ShutdownFire will fire all the pending timer immediately, the tick timer only fire once
+	ShutdownFire
+
+	// ShutdownWait will wait for the pending timer which expiration is before the ctx deadline
+	// to fire, and drop the others. The tick timer only fire once more.
+	ShutdownWait
+)
+
+// RecoverPolicy is the representation of how to deal with the overdue persistent timer when recover
+type RecoverPolicy int
+
+const (
+	// RecoverFire will fire the overdue persistent timer immediately after started
+	RecoverFire RecoverPolicy = iota
+
+	// RecoverDrop will drop the overdue persistent timer and delete it from the Store
+	RecoverDrop
+)
@@ -0,0 +1,127 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// timerCtx is the context which been canceled by the timertask of TimingWheel,
+// it's like the timerCtx of context package.
+type timerCtx struct {
+	context.Context
+
+	parent   context.Context
+	cancel   context.CancelFunc
+	deadline time.Time
+
+	mu  sync.Mutex
+	t   TimerTask
+	err error
+}
+
+func (c *timerCtx) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// Value lookups the parent instead of the embedded context, so the context package
+// will not treat the embedded context as the parent of child context, otherwise the
+// child will been canceled with context.Canceled rather than c.Err().
+func (c *timerCtx) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
+
+func (c *timerCtx) Err() error {
+	err := c.Context.Err()
+	if err == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return err
+}
+
+// expire cancels the context with context.DeadlineExceeded, it's the Handler of timertask
+func (c *timerCtx) expire(time.Time) {
+	c.mu.Lock()
+	if c.Context.Err() == nil {
+		c.err = context.DeadlineExceeded
+	}
+	c.mu.Unlock()
+	c.cancel()
+}
+
+// stop cancels the context with context.Canceled, and stop the timertask
+func (c *timerCtx) stop() {
+	c.mu.Lock()
+	t := c.t
+	c.mu.Unlock()
+	if t != nil {
+		_, _ = t.Stop()
+	}
+	c.cancel()
+}
+
+func (tw *timingWheel) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return tw.WithDeadline(parent, tw.c.Now().Add(d))
+}
+
+func (tw *timingWheel) WithDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	if cur, ok := parent.Deadline(); ok && cur.Before(deadline) {
+		// the current deadline is already sooner than the new one
+		return context.WithCancel(parent)
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	c := &timerCtx{
+		Context:  ctx,
+		parent:   parent,
+		cancel:   cancel,
+		deadline: deadline,
+	}
+
+	d := deadline.Sub(tw.c.Now())
+	if d <= 0 {
+		// the deadline has already passed
+		c.expire(deadline)
+		return c, c.stop
+	}
+
+	t, err := tw.AfterFunc(d, c.expire)
+	if err != nil {
+		// the wheel is stopped, fallback to the runtime timer
+		cancel()
+		return context.WithDeadline(parent, deadline)
+	}
+
+	c.mu.Lock()
+	c.t = t
+	c.mu.Unlock()
+	return c, c.stop
+}
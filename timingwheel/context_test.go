@@ -0,0 +1,143 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lsytj0413/ena/clock"
+	"github.com/stretchr/testify/suite"
+)
+
+type contextTestSuite struct {
+	suite.Suite
+
+	c  clock.FakeClock
+	tw TimingWheel
+}
+
+func (s *contextTestSuite) SetupTest() {
+	s.c = clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(s.c),
+	)
+	s.NoError(err)
+	s.tw = tw
+	s.tw.Start(context.Background())
+}
+
+func (s *contextTestSuite) TearDownTest() {
+	s.tw.Stop()
+}
+
+func (s *contextTestSuite) TestWithTimeout() {
+	ctx, cancel := s.tw.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	s.True(ok)
+	s.Equal(s.c.Now().Add(20*time.Millisecond), deadline)
+	s.NoError(ctx.Err())
+
+	for i := 0; i < 2; i++ {
+		s.c.BlockUntil(1)
+		s.c.Advance(10 * time.Millisecond)
+	}
+	<-ctx.Done()
+	s.Equal(context.DeadlineExceeded, ctx.Err())
+
+	// cancel after expired will not change the error
+	cancel()
+	s.Equal(context.DeadlineExceeded, ctx.Err())
+	s.Equal(uint64(1), s.tw.Stats().Fired)
+}
+
+func (s *contextTestSuite) TestCancel() {
+	ctx, cancel := s.tw.WithTimeout(context.Background(), 20*time.Millisecond)
+	cancel()
+	<-ctx.Done()
+	s.Equal(context.Canceled, ctx.Err())
+
+	stats := s.tw.Stats()
+	s.Equal(uint64(1), stats.Stopped)
+	s.Equal(0, stats.Pending)
+}
+
+func (s *contextTestSuite) TestParent() {
+	type key struct{}
+	parent, pcancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	ctx, cancel := s.tw.WithTimeout(parent, time.Second)
+	defer cancel()
+	s.Equal("value", ctx.Value(key{}))
+
+	pcancel()
+	<-ctx.Done()
+	s.Equal(context.Canceled, ctx.Err())
+}
+
+func (s *contextTestSuite) TestParentDeadline() {
+	parent, pcancel := s.tw.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer pcancel()
+
+	// the deadline of parent is sooner
+	ctx, cancel := s.tw.WithTimeout(parent, time.Second)
+	defer cancel()
+	deadline, ok := ctx.Deadline()
+	s.True(ok)
+	s.Equal(s.c.Now().Add(10*time.Millisecond), deadline)
+	s.Equal(1, s.tw.Stats().Pending)
+
+	s.c.BlockUntil(1)
+	s.c.Advance(10 * time.Millisecond)
+	<-ctx.Done()
+	s.Equal(context.DeadlineExceeded, ctx.Err())
+}
+
+func (s *contextTestSuite) TestExpired() {
+	ctx, cancel := s.tw.WithDeadline(context.Background(), s.c.Now().Add(-time.Second))
+	defer cancel()
+
+	<-ctx.Done()
+	s.Equal(context.DeadlineExceeded, ctx.Err())
+	s.Equal(0, s.tw.Stats().Pending)
+}
+
+func (s *contextTestSuite) TestStopped() {
+	s.tw.Stop()
+
+	ctx, cancel := s.tw.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	<-ctx.Done()
+	s.Equal(context.DeadlineExceeded, ctx.Err())
+}
+
+func TestContextTestSuite(t *testing.T) {
+	s := &contextTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,393 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+// The cron spec parsing and matching in this file are derived from the parser.go
+// and spec.go of github.com/robfig/cron, witch is distributed under the MIT License:
+//
+// Copyright (C) 2012 Rob Figueiredo
+// All Rights Reserved.
+//
+// MIT LICENSE
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package timingwheel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Every returns the Schedule which fires every duration d.
+func Every(d time.Duration) Schedule {
+	return everySchedule(d)
+}
+
+// everySchedule implement the Schedule with fixed interval
+type everySchedule time.Duration
+
+// Next implement Schedule.Next
+func (s everySchedule) Next(t time.Time) time.Time {
+	if s <= 0 {
+		return time.Time{}
+	}
+	return t.Add(time.Duration(s))
+}
+
+// cronBounds is the range of one cron field
+type cronBounds struct {
+	name  string
+	min   uint
+	max   uint
+	names map[string]uint
+}
+
+var (
+	secondBounds = cronBounds{name: "second", min: 0, max: 59}
+	minuteBounds = cronBounds{name: "minute", min: 0, max: 59}
+	hourBounds   = cronBounds{name: "hour", min: 0, max: 23}
+	domBounds    = cronBounds{name: "day of month", min: 1, max: 31}
+	monthBounds  = cronBounds{name: "month", min: 1, max: 12, names: map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// the 7 is also representation of sunday
+	dowBounds = cronBounds{name: "day of week", min: 0, max: 7, names: map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	// cronDescriptors is the predefined schedules
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// starBit is set when the field is "*" or "?", it's used to match day of month and day of week
+const starBit = 1 << 63
+
+// cronSchedule implement the Schedule with cron expression, every field is the bitset
+// of the matched values.
+type cronSchedule struct {
+	second uint64
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	// loc is the time zone which the expression is evaluated in
+	loc *time.Location
+}
+
+// ParseCron parse the cron expression into Schedule, the expression can be:
+//  1. standard 5 fields: minute, hour, day of month, month, day of week
+//  2. 6 fields with second: second, minute, hour, day of month, month, day of week
+//  3. predefined descriptors: @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly
+//  4. @every <duration>, EX: @every 1h30m
+//
+// Every field support the "*", "?", list "1,2", range "1-5", step "*/2" or "1-10/2",
+// and the name of month(JAN-DEC) and day of week(SUN-SAT).
+// The time zone can be specified by prefix "CRON_TZ=<location>" or "TZ=<location>",
+// EX: "CRON_TZ=Asia/Shanghai 0 8 * * *", the default time zone is time.Local.
+func ParseCron(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("ParseCron: EmptySpec")
+	}
+
+	loc := time.Local
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		i := strings.Index(spec, " ")
+		if i < 0 {
+			return nil, fmt.Errorf("ParseCron: MissingFields: Spec[%v]", spec)
+		}
+
+		name := spec[strings.Index(spec, "=")+1 : i]
+		var err error
+		loc, err = time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("ParseCron: InvalidTimeZone: Location[%v], Err[%v]", name, err)
+		}
+		spec = strings.TrimSpace(spec[i:])
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("ParseCron: InvalidDuration: Spec[%v]", spec)
+		}
+		return Every(d), nil
+	}
+	if strings.HasPrefix(spec, "@") {
+		v, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("ParseCron: UnknownDescriptor: Spec[%v]", spec)
+		}
+		spec = v
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("ParseCron: InvalidFieldCount: Spec[%v], Count[%v]", spec, len(fields))
+	}
+
+	s := &cronSchedule{
+		loc: loc,
+	}
+	var err error
+	for i, v := range []struct {
+		bits   *uint64
+		bounds cronBounds
+	}{
+		{&s.second, secondBounds},
+		{&s.minute, minuteBounds},
+		{&s.hour, hourBounds},
+		{&s.dom, domBounds},
+		{&s.month, monthBounds},
+		{&s.dow, dowBounds},
+	} {
+		if *v.bits, err = parseCronField(fields[i], v.bounds); err != nil {
+			return nil, err
+		}
+	}
+
+	// the 7 is the same as 0 for sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow = (s.dow &^ (1 << 7)) | 1
+	}
+	return s, nil
+}
+
+// parseCronField returns the bitset of the field, the field is comma separated list of ranges.
+func parseCronField(field string, b cronBounds) (uint64, error) {
+	var bits uint64
+	for _, expr := range strings.Split(field, ",") {
+		v, err := parseCronRange(expr, b)
+		if err != nil {
+			return 0, err
+		}
+		bits |= v
+	}
+	return bits, nil
+}
+
+// parseCronRange returns the bitset of the range expression, EX: "*", "1-5", "*/2", "3/5"
+func parseCronRange(expr string, b cronBounds) (uint64, error) {
+	invalid := func(reason string) error {
+		return fmt.Errorf("ParseCron: Invalid%v: Field[%v], Expr[%v]", reason, b.name, expr)
+	}
+
+	var start, end, step uint = 0, 0, 1
+	var extra uint64
+
+	rangeAndStep := strings.Split(expr, "/")
+	if len(rangeAndStep) > 2 {
+		return 0, invalid("Step")
+	}
+	lowAndHigh := strings.Split(rangeAndStep[0], "-")
+	if len(lowAndHigh) > 2 {
+		return 0, invalid("Range")
+	}
+
+	var err error
+	if lowAndHigh[0] == "*" || lowAndHigh[0] == "?" {
+		if len(lowAndHigh) != 1 {
+			return 0, invalid("Range")
+		}
+		start, end = b.min, b.max
+		extra = starBit
+	} else {
+		if start, err = parseCronValue(lowAndHigh[0], b); err != nil {
+			return 0, invalid("Value")
+		}
+		end = start
+		if len(lowAndHigh) == 2 {
+			if end, err = parseCronValue(lowAndHigh[1], b); err != nil {
+				return 0, invalid("Value")
+			}
+		}
+	}
+
+	if len(rangeAndStep) == 2 {
+		v, err := strconv.ParseUint(rangeAndStep[1], 10, 0)
+		if err != nil || v == 0 {
+			return 0, invalid("Step")
+		}
+		step = uint(v)
+
+		// "N/step" means "N-max/step"
+		if len(lowAndHigh) == 1 && extra == 0 {
+			end = b.max
+		}
+		if step > 1 {
+			extra = 0
+		}
+	}
+
+	if start < b.min || end > b.max || start > end {
+		return 0, invalid("Range")
+	}
+
+	var bits uint64
+	for i := start; i <= end; i += step {
+		bits |= 1 << i
+	}
+	return bits | extra, nil
+}
+
+// parseCronValue returns the value of number or name
+func parseCronValue(v string, b cronBounds) (uint, error) {
+	if n, ok := b.names[strings.ToLower(v)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.ParseUint(v, 10, 0)
+	if err != nil {
+		return 0, err
+	}
+	return uint(n), nil
+}
+
+// Next implement Schedule.Next, it returns the zero time if there is no matched time
+// in five years.
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	origLoc := t.Location()
+	t = t.In(s.loc)
+
+	// start at the next second
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+
+	// added is true if the time has been changed by the field, so the lower field
+	// should begin from the zero value.
+	added := false
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for 1<<uint(t.Month())&s.month == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, s.loc)
+		}
+		t = t.AddDate(0, 0, 1)
+
+		// the midnight may not exist because of daylight saving time, EX: it will be 01:00
+		// or 23:00 of the previous day, we adjust it to the start of day.
+		if t.Hour() != 0 {
+			if t.Hour() > 12 {
+				t = t.Add(time.Duration(24-t.Hour()) * time.Hour)
+			} else {
+				t = t.Add(time.Duration(-t.Hour()) * time.Hour)
+			}
+		}
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Hour())&s.hour == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, s.loc)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Minute())&s.minute == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for 1<<uint(t.Second())&s.second == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t.In(origLoc)
+}
+
+// dayMatches returns true if the day of month and day of week matched, if both of them
+// are restricted(not "*"), it's matched when either of them matched.
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := 1<<uint(t.Day())&s.dom > 0
+	dowMatch := 1<<uint(t.Weekday())&s.dow > 0
+	if s.dom&starBit > 0 || s.dow&starBit > 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,141 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type cronTestSuite struct {
+	suite.Suite
+}
+
+func (s *cronTestSuite) TestEvery() {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	s.Equal(now.Add(time.Minute), Every(time.Minute).Next(now))
+	s.True(Every(0).Next(now).IsZero())
+}
+
+func (s *cronTestSuite) TestParseCronFailed() {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"1/2/3 * * * *",
+		"1-2-3 * * * *",
+		"*-5 * * * *",
+		"a * * * *",
+		"* * * FOO *",
+		"@unknown",
+		"@every abc",
+		"@every -1s",
+		"TZ=Unknown/Zone * * * * *",
+		"CRON_TZ=UTC",
+	}
+	for _, spec := range specs {
+		s.Run(spec, func() {
+			v, err := ParseCron(spec)
+			s.Error(err)
+			s.Nil(v)
+		})
+	}
+}
+
+func (s *cronTestSuite) TestNext() {
+	type testCase struct {
+		spec   string
+		now    string
+		expect string
+	}
+	testCases := []testCase{
+		// 5 fields
+		{"TZ=UTC * * * * *", "2019-01-01T00:00:30Z", "2019-01-01T00:01:00Z"},
+		{"TZ=UTC 30 * * * *", "2019-01-01T00:30:00Z", "2019-01-01T01:30:00Z"},
+		{"TZ=UTC */15 * * * *", "2019-01-01T00:16:00Z", "2019-01-01T00:30:00Z"},
+		{"TZ=UTC 0 9-17/4 * * *", "2019-01-01T10:00:00Z", "2019-01-01T13:00:00Z"},
+		{"TZ=UTC 0 0 1,15 * *", "2019-01-02T00:00:00Z", "2019-01-15T00:00:00Z"},
+		{"TZ=UTC 0 0 31 * *", "2019-02-01T00:00:00Z", "2019-03-31T00:00:00Z"},
+		{"TZ=UTC 0 0 29 2 *", "2019-03-01T00:00:00Z", "2020-02-29T00:00:00Z"},
+		{"TZ=UTC 0 0 * * MON-FRI", "2019-01-04T12:00:00Z", "2019-01-07T00:00:00Z"},
+		{"TZ=UTC 0 0 * * 7", "2019-01-01T00:00:00Z", "2019-01-06T00:00:00Z"},
+		{"TZ=UTC 0 0 * JAN,jul ?", "2019-02-01T00:00:00Z", "2019-07-01T00:00:00Z"},
+		// both day of month and day of week are restricted
+		{"TZ=UTC 0 0 13 * FRI", "2019-01-01T00:00:00Z", "2019-01-04T00:00:00Z"},
+		{"TZ=UTC 0 0 13 * FRI", "2019-01-11T00:00:00Z", "2019-01-13T00:00:00Z"},
+		// 6 fields
+		{"TZ=UTC * * * * * *", "2019-01-01T00:00:00.5Z", "2019-01-01T00:00:01Z"},
+		{"TZ=UTC */10 * * * * *", "2019-01-01T00:00:05Z", "2019-01-01T00:00:10Z"},
+		{"TZ=UTC 5/20 * * * * *", "2019-01-01T00:00:26Z", "2019-01-01T00:00:45Z"},
+		{"TZ=UTC 0 0 0 1 1 *", "2019-06-01T00:00:00Z", "2020-01-01T00:00:00Z"},
+		// descriptors
+		{"TZ=UTC @hourly", "2019-01-01T00:10:00Z", "2019-01-01T01:00:00Z"},
+		{"TZ=UTC @daily", "2019-01-01T00:10:00Z", "2019-01-02T00:00:00Z"},
+		{"TZ=UTC @weekly", "2019-01-01T00:10:00Z", "2019-01-06T00:00:00Z"},
+		{"TZ=UTC @monthly", "2019-01-01T00:10:00Z", "2019-02-01T00:00:00Z"},
+		{"TZ=UTC @yearly", "2019-01-01T00:10:00Z", "2020-01-01T00:00:00Z"},
+		{"@every 1h30m", "2019-01-01T00:10:00Z", "2019-01-01T01:40:00Z"},
+		// time zone
+		{"CRON_TZ=Asia/Shanghai 0 8 * * *", "2019-01-01T00:00:00Z", "2019-01-02T00:00:00Z"},
+		{"CRON_TZ=Asia/Shanghai 0 9 * * *", "2019-01-01T00:00:00Z", "2019-01-01T01:00:00Z"},
+		// daylight saving time, 2019-03-10 02:00 doesn't exist in New York
+		{"TZ=America/New_York 0 0 * * *", "2019-03-09T12:00:00-05:00", "2019-03-10T00:00:00-05:00"},
+		{"TZ=America/New_York 30 2 * * *", "2019-03-10T00:00:00-05:00", "2019-03-11T02:30:00-04:00"},
+		// never
+		{"TZ=UTC 0 0 30 2 *", "2019-01-01T00:00:00Z", ""},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.spec+"@"+tc.now, func() {
+			sc, err := ParseCron(tc.spec)
+			s.NoError(err)
+
+			now, err := time.Parse(time.RFC3339Nano, tc.now)
+			s.NoError(err)
+
+			next := sc.Next(now)
+			if tc.expect == "" {
+				s.True(next.IsZero())
+				return
+			}
+
+			expect, err := time.Parse(time.RFC3339, tc.expect)
+			s.NoError(err)
+			s.True(expect.Equal(next), "expect[%v], got[%v]", expect, next)
+			s.Equal(now.Location(), next.Location())
+		})
+	}
+}
+
+func TestCronTestSuite(t *testing.T) {
+	s := &cronTestSuite{}
+	suite.Run(t, s)
+}
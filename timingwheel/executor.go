@@ -22,10 +22,122 @@
 
 package timingwheel
 
-import "time"
+import (
+	"context"
+	"sync"
+	"time"
 
-// executor is an redefine for task execute
-type executor = func(Handler, time.Time)
+	"github.com/lsytj0413/ena/conc"
+)
+
+// Executor is the interface to run the Handler when the timertask is fired
+type Executor interface {
+	// Execute runs the Handler with the fired time, it's called in the timingwheel's
+	// event loop, so it should not block for long.
+	Execute(f Handler, t time.Time)
+}
+
+// ExecutorFunc is an adapter to allow the use of ordinary functions as Executor
+type ExecutorFunc func(Handler, time.Time)
+
+// Execute implement Executor.Execute, it calls e(f, t)
+func (e ExecutorFunc) Execute(f Handler, t time.Time) {
+	e(f, t)
+}
+
+// TrackedExecutor is an Executor which tracks the submitted Handler, so the caller can
+// wait for them to complete. The TimingWheel.Shutdown use it to wait the running Handler,
+// if the Executor isn't an TrackedExecutor, the timingwheel will track the Handler by itself.
+type TrackedExecutor interface {
+	Executor
+
+	// Wait blocks until all the submitted Handler complete or the ctx is done,
+	// the Handler which is discarded by the Executor doesn't count.
+	Wait(ctx context.Context) error
+}
+
+// tracker is the counter of running Handler
+type tracker struct {
+	// protect the n and done
+	mu sync.Mutex
+
+	// n is the count of running Handler
+	n int
+
+	// done will been closed when n decrease to zero
+	done chan struct{}
+}
+
+// add increase the running count
+func (t *tracker) add() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.n == 0 {
+		t.done = make(chan struct{})
+	}
+	t.n++
+}
+
+// finish decrease the running count
+func (t *tracker) finish() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.n--
+	if t.n == 0 {
+		close(t.done)
+	}
+}
+
+// wait blocks until the running count decrease to zero or the ctx is done
+func (t *tracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.n == 0 {
+		t.mu.Unlock()
+		return nil
+	}
+	done := t.done
+	t.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// trackedExecutor wraps an Executor and tracks the Handler by itself
+type trackedExecutor struct {
+	e  Executor
+	tr tracker
+}
+
+// newTrackedExecutor returns e itself if it's an TrackedExecutor, otherwise wraps it.
+func newTrackedExecutor(e Executor) TrackedExecutor {
+	if te, ok := e.(TrackedExecutor); ok {
+		return te
+	}
+
+	return &trackedExecutor{
+		e: e,
+	}
+}
+
+// Execute implement Executor.Execute
+func (e *trackedExecutor) Execute(f Handler, t time.Time) {
+	e.tr.add()
+	e.e.Execute(func(t time.Time) {
+		defer e.tr.finish()
+		f(t)
+	}, t)
+}
+
+// Wait implement TrackedExecutor.Wait
+func (e *trackedExecutor) Wait(ctx context.Context) error {
+	return e.tr.wait(ctx)
+}
 
 // taskExecutor is the default implement to run Handler, will execute the Handler in other goroutine
 func taskExecutor(f Handler, ct time.Time) {
@@ -34,7 +146,7 @@ func taskExecutor(f Handler, ct time.Time) {
 
 var (
 	// defaultExecutor is the executor for task, it will execute the task in it's own goroutine
-	defaultExecutor executor
+	defaultExecutor Executor = ExecutorFunc(taskExecutor)
 )
 
 // blockExecutor will run task in current goruntine, for test usage
@@ -42,6 +154,207 @@ func blockExecutor(f Handler, ct time.Time) {
 	f(ct)
 }
 
-func init() {
-	defaultExecutor = taskExecutor
+// OverflowPolicy is the representation of how the PoolExecutor deal with the Handler
+// when the queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBacklog will hold the Handler in a backlog until the queue has space, the backlog
+	// is UNBOUNDED so the memory grows with the count of overflowed Handler, and the caller is
+	// never blocked. There is no blocking policy because the Execute is called in the
+	// timingwheel's event loop, blocking it will deadlock when the running Handler stops or
+	// resets a TimerTask.
+	OverflowBacklog OverflowPolicy = iota
+
+	// OverflowDrop will discard the Handler
+	OverflowDrop
+
+	// OverflowSpawn will run the Handler in a new goroutine outside the workers, the Handler
+	// isn't run in the caller's goroutine because it's the timingwheel's event loop.
+	OverflowSpawn
+)
+
+// PoolExecutor is an Executor which run the Handler with fixed count of worker goroutine.
+type PoolExecutor interface {
+	TrackedExecutor
+
+	// Stop stops the workers, it will wait for all queued Handler been executed.
+	// The Handler which is submitted after Stop will be discarded.
+	Stop()
+}
+
+// poolTask is the representation of value in the pool queue
+type poolTask struct {
+	f Handler
+	t time.Time
+}
+
+// poolExecutor is an implemention of PoolExecutor
+type poolExecutor struct {
+	// tasks is the queue of pending Handler, the workers consume it
+	tasks chan poolTask
+
+	// policy is the behavior when tasks is full
+	policy OverflowPolicy
+
+	// protect the stopped and the close of tasks
+	mu      sync.RWMutex
+	stopped bool
+
+	// backlog is the Handler held by OverflowBacklog when tasks is full, it's moved into
+	// tasks by the dispatch goroutine which is notified by backlogC
+	bmu      sync.Mutex
+	backlog  []poolTask
+	backlogC chan struct{}
+
+	// wg for wait worker goroutine
+	wg conc.WaitGroupWrapper
+
+	// tr tracks the queued and running Handler
+	tr tracker
+}
+
+// NewPoolExecutor creates an PoolExecutor with the workers count, the queueSize of
+// pending Handler and the policy when the queue is full. The count of pending Handler is
+// bounded by the queueSize only with OverflowDrop.
+func NewPoolExecutor(workers int, queueSize int, policy OverflowPolicy) (PoolExecutor, error) {
+	if workers <= 0 {
+		return nil, ErrInvalidWorkers
+	}
+	if queueSize < 0 {
+		return nil, ErrInvalidQueueSize
+	}
+	switch policy {
+	case OverflowBacklog, OverflowDrop, OverflowSpawn:
+	default:
+		return nil, ErrInvalidOverflowPolicy
+	}
+
+	p := &poolExecutor{
+		tasks:  make(chan poolTask, queueSize),
+		policy: policy,
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Wrap(p.work)
+	}
+	if policy == OverflowBacklog {
+		p.backlogC = make(chan struct{}, 1)
+		p.wg.Wrap(p.dispatch)
+	}
+	return p, nil
+}
+
+// dispatch moves the backlog into tasks, it closes the tasks after the backlogC closed
+func (p *poolExecutor) dispatch() {
+	defer close(p.tasks)
+
+	for range p.backlogC {
+		for {
+			// the task is kept in the backlog until it's sent, so the Execute will
+			// append to the backlog to keep the order
+			p.bmu.Lock()
+			if len(p.backlog) == 0 {
+				p.bmu.Unlock()
+				break
+			}
+			task := p.backlog[0]
+			p.bmu.Unlock()
+
+			p.tasks <- task
+
+			p.bmu.Lock()
+			p.backlog[0] = poolTask{}
+			p.backlog = p.backlog[1:]
+			p.bmu.Unlock()
+		}
+	}
+}
+
+func (p *poolExecutor) work() {
+	for task := range p.tasks {
+		p.run(task.f, task.t)
+	}
+}
+
+// Execute implement Executor.Execute
+func (p *poolExecutor) Execute(f Handler, t time.Time) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return
+	}
+
+	task := poolTask{
+		f: f,
+		t: t,
+	}
+	p.tr.add()
+	if p.policy == OverflowBacklog {
+		p.hold(task)
+		return
+	}
+
+	select {
+	case p.tasks <- task:
+	default:
+		if p.policy == OverflowSpawn {
+			p.wg.Wrap(func() {
+				p.run(f, t)
+			})
+			return
+		}
+		p.tr.finish()
+	}
+}
+
+// hold sends the task into tasks if there is space, otherwise append it to the backlog,
+// it must been called with the mu read locked
+func (p *poolExecutor) hold(task poolTask) {
+	p.bmu.Lock()
+	if len(p.backlog) == 0 {
+		select {
+		case p.tasks <- task:
+			p.bmu.Unlock()
+			return
+		default:
+		}
+	}
+	p.backlog = append(p.backlog, task)
+	p.bmu.Unlock()
+
+	select {
+	case p.backlogC <- struct{}{}:
+	default:
+	}
+}
+
+// run the Handler and mark it finished
+func (p *poolExecutor) run(f Handler, t time.Time) {
+	defer p.tr.finish()
+	f(t)
+}
+
+// Wait implement TrackedExecutor.Wait
+func (p *poolExecutor) Wait(ctx context.Context) error {
+	return p.tr.wait(ctx)
+}
+
+// Stop implement PoolExecutor.Stop
+func (p *poolExecutor) Stop() {
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	p.stopped = true
+	if p.backlogC != nil {
+		// the dispatch will close the tasks after the backlog been moved
+		close(p.backlogC)
+	} else {
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	p.wg.Wait()
 }
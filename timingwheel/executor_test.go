@@ -0,0 +1,297 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/lsytj0413/ena/clock"
+)
+
+type executorTestSuite struct {
+	suite.Suite
+}
+
+func (s *executorTestSuite) TestExecutorFunc() {
+	v := time.Time{}
+	now := time.Now()
+	ExecutorFunc(blockExecutor).Execute(func(t time.Time) {
+		v = t
+	}, now)
+	s.Equal(now, v)
+}
+
+func (s *executorTestSuite) TestNewPoolExecutorFailed() {
+	type testCase struct {
+		desp      string
+		workers   int
+		queueSize int
+		policy    OverflowPolicy
+		err       error
+	}
+	testCases := []testCase{
+		{
+			desp:      "zero workers",
+			workers:   0,
+			queueSize: 1,
+			policy:    OverflowBacklog,
+			err:       ErrInvalidWorkers,
+		},
+		{
+			desp:      "negative queue size",
+			workers:   1,
+			queueSize: -1,
+			policy:    OverflowBacklog,
+			err:       ErrInvalidQueueSize,
+		},
+		{
+			desp:      "unknown policy",
+			workers:   1,
+			queueSize: 1,
+			policy:    OverflowPolicy(100),
+			err:       ErrInvalidOverflowPolicy,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.desp, func() {
+			p, err := NewPoolExecutor(tc.workers, tc.queueSize, tc.policy)
+			s.Equal(tc.err, err)
+			s.Nil(p)
+		})
+	}
+}
+
+func (s *executorTestSuite) TestPoolExecutorExecute() {
+	p, err := NewPoolExecutor(4, 16, OverflowBacklog)
+	s.NoError(err)
+
+	var c int32
+	for i := 0; i < 100; i++ {
+		p.Execute(func(time.Time) {
+			atomic.AddInt32(&c, 1)
+		}, time.Now())
+	}
+	p.Stop()
+	s.Equal(int32(100), atomic.LoadInt32(&c))
+
+	// discard after stop
+	p.Execute(func(time.Time) {
+		atomic.AddInt32(&c, 1)
+	}, time.Now())
+	p.Stop()
+	s.Equal(int32(100), atomic.LoadInt32(&c))
+}
+
+// blockPool returns an PoolExecutor with one worker and a full queue, the worker
+// is blocked until the returned func is called.
+func (s *executorTestSuite) blockPool(policy OverflowPolicy) (PoolExecutor, func()) {
+	p, err := NewPoolExecutor(1, 1, policy)
+	s.NoError(err)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	started := make(chan struct{})
+	p.Execute(func(time.Time) {
+		close(started)
+		wg.Wait()
+	}, time.Now())
+	<-started
+	p.Execute(func(time.Time) {}, time.Now())
+
+	return p, wg.Done
+}
+
+func (s *executorTestSuite) TestPoolExecutorOverflowDrop() {
+	p, release := s.blockPool(OverflowDrop)
+
+	called := false
+	p.Execute(func(time.Time) {
+		called = true
+	}, time.Now())
+
+	release()
+	p.Stop()
+	s.False(called)
+}
+
+func (s *executorTestSuite) TestPoolExecutorOverflowSpawn() {
+	p, release := s.blockPool(OverflowSpawn)
+
+	called := make(chan struct{})
+	p.Execute(func(time.Time) {
+		close(called)
+	}, time.Now())
+	<-called
+
+	release()
+	p.Stop()
+}
+
+func (s *executorTestSuite) TestPoolExecutorOverflowSpawnStopInHandler() {
+	p, release := s.blockPool(OverflowSpawn)
+	defer p.Stop()
+	defer release()
+
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(p),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	// the Handler stops the ticker while the worker is busy, it must not been run
+	// in the event loop which is waiting for itself
+	var tk TimerTask
+	stopped := make(chan bool, 1)
+	tk, err = tw.TickFunc(10*time.Millisecond, func(time.Time) {
+		ok, _ := tk.Stop()
+		stopped <- ok
+	})
+	s.NoError(err)
+
+	c.BlockUntil(1)
+	c.Advance(10 * time.Millisecond)
+	select {
+	case ok := <-stopped:
+		s.True(ok)
+	case <-time.After(time.Second):
+		s.FailNow("the timingwheel is deadlocked")
+	}
+	s.Equal(uint64(1), tw.Stats().Stopped)
+}
+
+func (s *executorTestSuite) TestPoolExecutorOverflowBacklog() {
+	p, release := s.blockPool(OverflowBacklog)
+
+	// the Execute doesn't block, the Handler is held in the backlog by order
+	var mu sync.Mutex
+	var vs []int
+	for i := 0; i < 10; i++ {
+		i := i
+		p.Execute(func(time.Time) {
+			mu.Lock()
+			defer mu.Unlock()
+			vs = append(vs, i)
+		}, time.Now())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	s.Equal(context.DeadlineExceeded, p.Wait(ctx))
+
+	release()
+	p.Stop()
+	s.Equal([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, vs)
+}
+
+func (s *executorTestSuite) TestPoolExecutorOverflowBacklogStopInHandler() {
+	p, err := NewPoolExecutor(1, 0, OverflowBacklog)
+	s.NoError(err)
+	defer p.Stop()
+
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(p),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	// the fired Handler stops the other one, the event loop must not been blocked by the
+	// Execute while the worker is waiting for the event loop
+	var tasks [2]TimerTask
+	done := make(chan struct{}, 2)
+	for i := range tasks {
+		other := &tasks[1-i]
+		tasks[i], err = tw.AfterFunc(10*time.Millisecond, func(time.Time) {
+			(*other).Stop()
+			done <- struct{}{}
+		})
+		s.NoError(err)
+	}
+
+	c.BlockUntil(1)
+	c.Advance(10 * time.Millisecond)
+	for range tasks {
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			s.FailNow("the timingwheel is deadlocked")
+		}
+	}
+}
+
+func (s *executorTestSuite) TestTrackedExecutor() {
+	e := newTrackedExecutor(ExecutorFunc(taskExecutor))
+	s.NoError(e.Wait(context.Background()))
+
+	release := make(chan struct{})
+	e.Execute(func(time.Time) {
+		<-release
+	}, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	s.Equal(context.DeadlineExceeded, e.Wait(ctx))
+
+	close(release)
+	s.NoError(e.Wait(context.Background()))
+
+	p, err := NewPoolExecutor(1, 1, OverflowBacklog)
+	s.NoError(err)
+	s.Equal(p, newTrackedExecutor(p))
+	p.Stop()
+}
+
+func (s *executorTestSuite) TestPoolExecutorWait() {
+	p, release := s.blockPool(OverflowDrop)
+
+	// the dropped Handler doesn't count
+	p.Execute(func(time.Time) {}, time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	s.Equal(context.DeadlineExceeded, p.Wait(ctx))
+
+	release()
+	s.NoError(p.Wait(context.Background()))
+	p.Stop()
+}
+
+func TestExecutorTestSuite(t *testing.T) {
+	s := &executorTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,142 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// group is an implemention of Group, the member timertask is tracked until fired or stopped
+type group struct {
+	tw *timingWheel
+
+	// mu protect the members and stopped
+	mu      sync.Mutex
+	members map[*timerTask]struct{}
+	stopped bool
+
+	// done will been closed after StopAll called
+	done chan struct{}
+}
+
+func newGroup(ctx context.Context, tw *timingWheel) *group {
+	g := &group{
+		tw:      tw,
+		members: make(map[*timerTask]struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if ctx.Done() != nil {
+		go func() {
+			select {
+			case <-ctx.Done():
+				_, _ = g.StopAll()
+			case <-g.done:
+			case <-tw.done:
+			}
+		}()
+	}
+	return g
+}
+
+func (g *group) AfterFunc(d time.Duration, f Handler) (TimerTask, error) {
+	return g.tw.addFunc(d, contextHandler(f), taskAfter, tickOption{}, g)
+}
+
+func (g *group) TickFunc(d time.Duration, f Handler, opts ...TickOption) (TimerTask, error) {
+	return g.tw.tickFunc(d, contextHandler(f), opts, g)
+}
+
+func (g *group) ScheduleFunc(s Schedule, f Handler) (TimerTask, error) {
+	return g.tw.scheduleFunc(s, contextHandler(f), g)
+}
+
+func (g *group) Len() int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return len(g.members)
+}
+
+func (g *group) StopAll() (int, error) {
+	g.mu.Lock()
+	if g.stopped {
+		g.mu.Unlock()
+		return 0, nil
+	}
+	g.stopped = true
+	close(g.done)
+	g.mu.Unlock()
+
+	v, err := g.tw.request(event{
+		Type: eventStopGroup,
+		g:    g,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return v.(int), nil
+}
+
+// join add the timertask into the group, it returns ErrGroupStopped after StopAll called
+func (g *group) join(t *timerTask) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stopped {
+		return ErrGroupStopped
+	}
+	g.members[t] = struct{}{}
+	return nil
+}
+
+// leave remove the timertask from the group
+func (g *group) leave(t *timerTask) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.members, t)
+}
+
+// isStopped returns true after StopAll called
+func (g *group) isStopped() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.stopped
+}
+
+// drain returns all the member timertask and clear the group
+func (g *group) drain() []*timerTask {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	members := make([]*timerTask, 0, len(g.members))
+	for t := range g.members {
+		members = append(members, t)
+	}
+	g.members = make(map[*timerTask]struct{})
+	return members
+}
@@ -0,0 +1,179 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lsytj0413/ena/clock"
+	"github.com/stretchr/testify/suite"
+)
+
+type groupTestSuite struct {
+	suite.Suite
+
+	c  clock.FakeClock
+	tw TimingWheel
+}
+
+func (s *groupTestSuite) SetupTest() {
+	s.c = clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(s.c),
+	)
+	s.NoError(err)
+	s.tw = tw
+	s.tw.Start(context.Background())
+}
+
+func (s *groupTestSuite) TearDownTest() {
+	s.tw.Stop()
+}
+
+func (s *groupTestSuite) TestStopAll() {
+	ch := make(chan time.Time, 10)
+	f := func(t time.Time) {
+		ch <- t
+	}
+
+	g := s.tw.NewGroup(context.Background())
+	_, err := g.AfterFunc(10*time.Millisecond, f)
+	s.NoError(err)
+	_, err = g.AfterFunc(time.Hour, f)
+	s.NoError(err)
+	_, err = g.TickFunc(10*time.Millisecond, f)
+	s.NoError(err)
+	_, err = g.ScheduleFunc(Every(10*time.Millisecond), f)
+	s.NoError(err)
+	s.Equal(4, g.Len())
+
+	// the timer outside the group will not been stopped
+	_, err = s.tw.AfterFunc(20*time.Millisecond, f)
+	s.NoError(err)
+
+	n, err := g.StopAll()
+	s.NoError(err)
+	s.Equal(4, n)
+	s.Equal(0, g.Len())
+	s.Equal(uint64(4), s.tw.Stats().Stopped)
+
+	for i := 0; i < 2; i++ {
+		s.c.BlockUntil(1)
+		s.c.Advance(10 * time.Millisecond)
+	}
+	s.Equal(s.c.Now(), <-ch)
+	select {
+	case <-ch:
+		s.FailNow("expect the timer in group not fired")
+	default:
+	}
+
+	_, err = g.AfterFunc(time.Second, f)
+	s.Equal(ErrGroupStopped, err)
+	n, err = g.StopAll()
+	s.NoError(err)
+	s.Equal(0, n)
+}
+
+func (s *groupTestSuite) TestLeave() {
+	ch := make(chan time.Time, 10)
+	g := s.tw.NewGroup(context.Background())
+	t1, err := g.AfterFunc(10*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+	t2, err := g.AfterFunc(time.Second, func(time.Time) {})
+	s.NoError(err)
+	t3, err := g.TickFunc(time.Second, func(time.Time) {})
+	s.NoError(err)
+	s.Equal(3, g.Len())
+
+	// the after timer leave the group after fired
+	s.c.BlockUntil(1)
+	s.c.Advance(10 * time.Millisecond)
+	<-ch
+	s.Equal(2, g.Len())
+
+	// the timer leave the group after stopped
+	stopped, err := t2.Stop()
+	s.NoError(err)
+	s.True(stopped)
+	s.Equal(1, g.Len())
+
+	// the timer join the group again after reset
+	_, err = t1.Reset(time.Second)
+	s.NoError(err)
+	s.Equal(2, g.Len())
+
+	n, err := g.StopAll()
+	s.NoError(err)
+	s.Equal(2, n)
+	stopped, err = t3.Stop()
+	s.NoError(err)
+	s.True(stopped)
+
+	_, err = t1.Reset(time.Second)
+	s.Equal(ErrGroupStopped, err)
+}
+
+func (s *groupTestSuite) TestContextDone() {
+	ctx, cancel := context.WithCancel(context.Background())
+	g := s.tw.NewGroup(ctx)
+	t, err := g.AfterFunc(time.Second, func(time.Time) {})
+	s.NoError(err)
+
+	cancel()
+	s.Eventually(func() bool {
+		_, err := g.AfterFunc(time.Second, func(time.Time) {})
+		return err == ErrGroupStopped
+	}, time.Second, time.Millisecond)
+	s.Eventually(func() bool {
+		return g.Len() == 0
+	}, time.Second, time.Millisecond)
+
+	stopped, err := t.Stop()
+	s.NoError(err)
+	s.True(stopped)
+}
+
+func (s *groupTestSuite) TestWheelStopped() {
+	g := s.tw.NewGroup(context.Background())
+	s.tw.Stop()
+
+	_, err := g.AfterFunc(time.Second, func(time.Time) {})
+	s.Equal(ErrWheelStopped, err)
+	s.Equal(0, g.Len())
+
+	_, err = g.StopAll()
+	s.Equal(ErrWheelStopped, err)
+}
+
+func TestGroupTestSuite(t *testing.T) {
+	s := &groupTestSuite{}
+	suite.Run(t, s)
+}
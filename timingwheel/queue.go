@@ -0,0 +1,104 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"sync"
+)
+
+// defaultQueueShards is the shard count of eventQueue, must be power of two
+const defaultQueueShards = 8
+
+// eventQueue is an multi-producer single-consumer queue of event, the producers
+// will never been blocked, and the consumer takes all the pending event in batch.
+// The event is sharded by the timertask id, so the events of same timertask
+// always keep the order.
+type eventQueue struct {
+	shards [defaultQueueShards]eventShard
+
+	// c is the signal of there is pending event, it's bufferd with size one,
+	// so the multi Push will only send one signal.
+	c chan struct{}
+}
+
+// eventShard is the pending events of one shard
+type eventShard struct {
+	mu sync.Mutex
+
+	// events is the pending events
+	events []event
+
+	// spare is the slice which is returned from the last take, reuse to avoid alloc
+	spare []event
+}
+
+func newEventQueue() *eventQueue {
+	return &eventQueue{
+		c: make(chan struct{}, 1),
+	}
+}
+
+// Push the event into the queue, it's goroutine-safe.
+func (q *eventQueue) Push(e event) {
+	// the events of same timertask is put into the same shard to keep the order
+	key := e.rid
+	if e.t != nil {
+		key = e.t.id
+	}
+	s := &q.shards[key&(defaultQueueShards-1)]
+	s.mu.Lock()
+	s.events = append(s.events, e)
+	s.mu.Unlock()
+
+	select {
+	case q.c <- struct{}{}:
+	default:
+		// there is an signal haven't been consumed
+	}
+}
+
+// C returns the signal channel, the consumer should call Take after received
+func (q *eventQueue) C() <-chan struct{} {
+	return q.c
+}
+
+// Take calls fn with all the pending events, it's must been called by only one consumer.
+func (q *eventQueue) Take(fn func(event)) {
+	for i := range q.shards {
+		s := &q.shards[i]
+		s.mu.Lock()
+		events := s.events
+		s.events, s.spare = s.spare[:0], nil
+		s.mu.Unlock()
+
+		for j := range events {
+			fn(events[j])
+			// set element to zero for GC
+			events[j] = event{}
+		}
+
+		s.mu.Lock()
+		s.spare = events[:0]
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,73 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+package timingwheel
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lsytj0413/ena/conc"
+)
+
+func Benchmark_EventQueue_Parallel(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg conc.WaitGroupWrapper
+	q := newEventQueue()
+
+	// the single consumer like the event loop of timingwheel
+	n := 0
+	wg.Wrap(func() {
+		for {
+			select {
+			case <-q.C():
+				q.Take(func(event) {
+					n++
+				})
+			case <-ctx.Done():
+				q.Take(func(event) {
+					n++
+				})
+				return
+			}
+		}
+	})
+
+	var id uint64
+	b.RunParallel(func(p *testing.PB) {
+		t := &timerTask{
+			id: atomic.AddUint64(&id, 1),
+		}
+		for p.Next() {
+			q.Push(event{
+				Type: eventAddNew,
+				t:    t,
+			})
+		}
+	})
+
+	cancel()
+	wg.Wait()
+	if n != b.N {
+		b.Fatalf("expect %d events, got %d", b.N, n)
+	}
+}
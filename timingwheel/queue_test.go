@@ -0,0 +1,125 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type eventQueueTestSuite struct {
+	suite.Suite
+
+	q *eventQueue
+}
+
+func (s *eventQueueTestSuite) SetupTest() {
+	s.q = newEventQueue()
+}
+
+func (s *eventQueueTestSuite) TestPush() {
+	s.q.Push(event{
+		Type: eventAddNew,
+		t:    &timerTask{id: 1},
+	})
+	s.q.Push(event{
+		Type: eventDelete,
+		t:    &timerTask{id: 2},
+	})
+
+	// only one signal for multi push
+	<-s.q.C()
+	select {
+	case <-s.q.C():
+		s.FailNow("expect only one signal")
+	default:
+	}
+
+	ids := []uint64{}
+	s.q.Take(func(e event) {
+		ids = append(ids, e.t.id)
+	})
+	s.ElementsMatch([]uint64{1, 2}, ids)
+
+	// nothing left
+	s.q.Take(func(e event) {
+		s.FailNow("expect empty queue")
+	})
+}
+
+func (s *eventQueueTestSuite) TestTakeOrder() {
+	t := &timerTask{id: 3}
+	types := []eventType{eventAddNew, eventDelete, eventAddNew, eventDelete}
+	for _, v := range types {
+		s.q.Push(event{
+			Type: v,
+			t:    t,
+		})
+	}
+
+	got := []eventType{}
+	s.q.Take(func(e event) {
+		got = append(got, e.Type)
+	})
+	s.Equal(types, got)
+}
+
+func (s *eventQueueTestSuite) TestPushParallel() {
+	var wg sync.WaitGroup
+	n, m := 8, 1000
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < m; j++ {
+				s.q.Push(event{
+					Type: eventAddNew,
+					t:    &timerTask{id: uint64(i*m + j)},
+				})
+			}
+		}(i)
+	}
+
+	c := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for c < n*m {
+			<-s.q.C()
+			s.q.Take(func(event) {
+				c++
+			})
+		}
+	}()
+
+	wg.Wait()
+	<-done
+	s.Equal(n*m, c)
+}
+
+func TestEventQueueTestSuite(t *testing.T) {
+	s := &eventQueueTestSuite{}
+	suite.Run(t, s)
+}
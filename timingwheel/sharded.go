@@ -0,0 +1,218 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"hash/fnv"
+	"sync/atomic"
+	"time"
+
+	"github.com/lsytj0413/ena/conc"
+)
+
+const (
+	// maxShards is the max count of wheels in the ShardedTimingWheel
+	maxShards = 256
+
+	// shardIDShift is the bit shift of shard index in the timertask id, so the id of timertask
+	// is unique in the ShardedTimingWheel, and the low bits is still incremental.
+	shardIDShift = 56
+)
+
+// ShardedTimingWheel is the TimingWheel which fans timers across independent wheels,
+// every wheel has its own delayqueue and event loop.
+type ShardedTimingWheel interface {
+	TimingWheel
+
+	// Shard returns the wheel which the key belongs to, it can be used to put the timers
+	// with same key into the same wheel. The persistent timer is always put into the
+	// wheel by its key.
+	Shard(key string) TimingWheel
+}
+
+// NewShardedTimingWheel creates an instance of ShardedTimingWheel with n wheels, every wheel
+// is created by the opts. The timers without key is put into the wheels by round-robin.
+func NewShardedTimingWheel(n int, opts ...Option) (ShardedTimingWheel, error) {
+	if n <= 0 || n > maxShards {
+		return nil, ErrInvalidShards
+	}
+
+	stw := &shardedTimingWheel{
+		wheels: make([]TimingWheel, n),
+	}
+	for i := range stw.wheels {
+		shard, shards := uint64(i), uint64(n)
+		sopts := append(append([]Option{}, opts...), optionFunc(func(opt *option) {
+			opt.shard, opt.shards = shard, shards
+		}))
+
+		tw, err := NewTimingWheel(sopts...)
+		if err != nil {
+			return nil, err
+		}
+		stw.wheels[i] = tw
+	}
+	return stw, nil
+}
+
+// shardOf returns the shard index of the key
+func shardOf(key string, shards uint64) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	return h.Sum64() % shards
+}
+
+// shardedTimingWheel is an implemention of ShardedTimingWheel
+type shardedTimingWheel struct {
+	wheels []TimingWheel
+
+	// next is the round-robin counter
+	next uint64
+}
+
+// pick returns the next wheel by round-robin
+func (stw *shardedTimingWheel) pick() TimingWheel {
+	n := atomic.AddUint64(&stw.next, 1)
+	return stw.wheels[n%uint64(len(stw.wheels))]
+}
+
+func (stw *shardedTimingWheel) Shard(key string) TimingWheel {
+	return stw.wheels[shardOf(key, uint64(len(stw.wheels)))]
+}
+
+func (stw *shardedTimingWheel) Start(ctx context.Context) {
+	for _, tw := range stw.wheels {
+		tw.Start(ctx)
+	}
+}
+
+func (stw *shardedTimingWheel) Stop() {
+	var wg conc.WaitGroupWrapper
+	for _, tw := range stw.wheels {
+		tw := tw
+		wg.Wrap(tw.Stop)
+	}
+	wg.Wait()
+}
+
+func (stw *shardedTimingWheel) Shutdown(ctx context.Context, mode ShutdownMode) error {
+	var wg conc.WaitGroupWrapper
+	errs := make([]error, len(stw.wheels))
+	for i, tw := range stw.wheels {
+		i, tw := i, tw
+		wg.Wrap(func() {
+			errs[i] = tw.Shutdown(ctx, mode)
+		})
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (stw *shardedTimingWheel) AfterFunc(d time.Duration, f Handler) (TimerTask, error) {
+	return stw.pick().AfterFunc(d, f)
+}
+
+func (stw *shardedTimingWheel) TickFunc(d time.Duration, f Handler, opts ...TickOption) (TimerTask, error) {
+	return stw.pick().TickFunc(d, f, opts...)
+}
+
+func (stw *shardedTimingWheel) ScheduleFunc(s Schedule, f Handler) (TimerTask, error) {
+	return stw.pick().ScheduleFunc(s, f)
+}
+
+func (stw *shardedTimingWheel) AfterContextFunc(d time.Duration, f ContextHandler) (TimerTask, error) {
+	return stw.pick().AfterContextFunc(d, f)
+}
+
+func (stw *shardedTimingWheel) TickContextFunc(d time.Duration, f ContextHandler, opts ...TickOption) (TimerTask, error) {
+	return stw.pick().TickContextFunc(d, f, opts...)
+}
+
+func (stw *shardedTimingWheel) ScheduleContextFunc(s Schedule, f ContextHandler) (TimerTask, error) {
+	return stw.pick().ScheduleContextFunc(s, f)
+}
+
+func (stw *shardedTimingWheel) AfterPersistFunc(key string, d time.Duration, payload []byte) (TimerTask, error) {
+	return stw.Shard(key).AfterPersistFunc(key, d, payload)
+}
+
+func (stw *shardedTimingWheel) Persistent(key string) (TimerTask, bool) {
+	return stw.Shard(key).Persistent(key)
+}
+
+func (stw *shardedTimingWheel) NewTimer(d time.Duration) (*Timer, error) {
+	return stw.pick().NewTimer(d)
+}
+
+func (stw *shardedTimingWheel) NewTicker(d time.Duration, opts ...TickOption) (*Ticker, error) {
+	return stw.pick().NewTicker(d, opts...)
+}
+
+func (stw *shardedTimingWheel) WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return stw.pick().WithTimeout(parent, d)
+}
+
+func (stw *shardedTimingWheel) WithDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc) {
+	return stw.pick().WithDeadline(parent, deadline)
+}
+
+// NewGroup creates the Group in one of the wheels, so the StopAll is done in single event loop.
+func (stw *shardedTimingWheel) NewGroup(ctx context.Context) Group {
+	return stw.pick().NewGroup(ctx)
+}
+
+// Stats returns the aggregated Stats of all wheels, the Lag is the max one.
+func (stw *shardedTimingWheel) Stats() Stats {
+	ret := Stats{}
+	for _, tw := range stw.wheels {
+		st := tw.Stats()
+		for i, l := range st.Layers {
+			if i >= len(ret.Layers) {
+				ret.Layers = append(ret.Layers, LayerStats{
+					Tick:      l.Tick,
+					Interval:  l.Interval,
+					WheelSize: l.WheelSize,
+				})
+			}
+			ret.Layers[i].Occupied += l.Occupied
+			ret.Layers[i].Timers += l.Timers
+		}
+
+		ret.Pending += st.Pending
+		ret.Buckets += st.Buckets
+		ret.Fired += st.Fired
+		ret.Stopped += st.Stopped
+		ret.Panics += st.Panics
+		if st.Lag > ret.Lag {
+			ret.Lag = st.Lag
+		}
+	}
+	return ret
+}
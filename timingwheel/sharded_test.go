@@ -0,0 +1,165 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lsytj0413/ena/clock"
+	"github.com/lsytj0413/ena/delayqueue"
+	"github.com/stretchr/testify/suite"
+)
+
+type shardedTimingWheelTestSuite struct {
+	suite.Suite
+
+	c   clock.FakeClock
+	stw ShardedTimingWheel
+}
+
+func (s *shardedTimingWheelTestSuite) SetupTest() {
+	s.c = clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	stw, err := NewShardedTimingWheel(4,
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(s.c),
+	)
+	s.NoError(err)
+	s.stw = stw
+}
+
+func (s *shardedTimingWheelTestSuite) TestInvalid() {
+	for _, n := range []int{-1, 0, maxShards + 1} {
+		_, err := NewShardedTimingWheel(n)
+		s.Equal(ErrInvalidShards, err)
+	}
+
+	_, err := NewShardedTimingWheel(2, WithSize(0))
+	s.Equal(ErrInvalidWheelSize, err)
+
+	_, err = NewShardedTimingWheel(2, WithDelayQueue(delayqueue.New(1)))
+	s.Equal(ErrInvalidDelayQueue, err)
+}
+
+func (s *shardedTimingWheelTestSuite) TestRoundRobin() {
+	ids := map[uint64]bool{}
+	for i := 0; i < 8; i++ {
+		t, err := s.stw.AfterFunc(time.Second, func(time.Time) {})
+		s.NoError(err)
+		ids[t.ID()] = true
+	}
+	s.Equal(8, len(ids))
+
+	s.stw.Start(context.Background())
+	defer s.stw.Stop()
+
+	st := s.stw.Stats()
+	s.Equal(8, st.Pending)
+	s.Equal(2, len(st.Layers))
+	s.Equal(8, st.Layers[1].Timers)
+	for _, tw := range s.stw.(*shardedTimingWheel).wheels {
+		s.Equal(2, tw.Stats().Pending)
+	}
+}
+
+func (s *shardedTimingWheelTestSuite) TestFire() {
+	ch := make(chan time.Time, 4)
+	for i := 0; i < 4; i++ {
+		_, err := s.stw.AfterFunc(10*time.Millisecond, func(t time.Time) {
+			ch <- t
+		})
+		s.NoError(err)
+	}
+
+	s.stw.Start(context.Background())
+	defer s.stw.Stop()
+
+	// every wheel has its own delayqueue sleeping for the bucket
+	s.c.BlockUntil(4)
+	s.c.Advance(10 * time.Millisecond)
+	for i := 0; i < 4; i++ {
+		<-ch
+	}
+	s.Equal(uint64(4), s.stw.Stats().Fired)
+}
+
+func (s *shardedTimingWheelTestSuite) TestShard() {
+	tw := s.stw.Shard("key")
+	s.Equal(tw, s.stw.Shard("key"))
+
+	t, err := tw.AfterFunc(time.Second, func(time.Time) {})
+	s.NoError(err)
+	s.Equal(shardOf("key", 4), t.ID()>>shardIDShift)
+}
+
+func (s *shardedTimingWheelTestSuite) TestShutdown() {
+	s.stw.Start(context.Background())
+	s.NoError(s.stw.Shutdown(context.Background(), ShutdownDrop))
+	s.Equal(ErrWheelStopped, s.stw.Shutdown(context.Background(), ShutdownDrop))
+	s.stw.Stop()
+
+	_, err := s.stw.AfterFunc(time.Second, func(time.Time) {})
+	s.Equal(ErrWheelStopped, err)
+}
+
+func (s *shardedTimingWheelTestSuite) TestPersistent() {
+	st, err := NewFileStore(filepath.Join(s.T().TempDir(), "timers.log"))
+	s.NoError(err)
+	defer st.Close()
+
+	h := func(context.Context, string, []byte, time.Time) error {
+		return nil
+	}
+	opts := []Option{WithClock(s.c), WithStore(st, h)}
+	stw, err := NewShardedTimingWheel(4, opts...)
+	s.NoError(err)
+
+	keys := []string{"a", "b", "c", "d", "e", "f"}
+	for _, key := range keys {
+		t, err := stw.AfterPersistFunc(key, time.Hour, nil)
+		s.NoError(err)
+		s.Equal(shardOf(key, 4), t.ID()>>shardIDShift)
+	}
+	stw.Stop()
+
+	// every persistent timer is only recovered by the wheel it belongs to
+	stw, err = NewShardedTimingWheel(4, opts...)
+	s.NoError(err)
+	defer stw.Stop()
+	for _, key := range keys {
+		_, ok := stw.Persistent(key)
+		s.True(ok)
+	}
+
+	stw.Start(context.Background())
+	s.Equal(len(keys), stw.Stats().Pending)
+}
+
+func TestShardedTimingWheelTestSuite(t *testing.T) {
+	s := &shardedTimingWheelTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,92 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Stats is the snapshot of the TimingWheel's state
+type Stats struct {
+	// Layers is the stats of every layer wheel, the first one is the lowest layer
+	Layers []LayerStats
+
+	// Pending is the count of timertask in the wheel which wait to been fired
+	Pending int
+
+	// Buckets is the count of bucket in the delayqueue which wait to been expired
+	Buckets int
+
+	// Fired is the count of Handler which has been submitted to the Executor
+	Fired uint64
+
+	// Stopped is the count of timertask which has been stopped by TimerTask.Stop
+	Stopped uint64
+
+	// Panics is the count of Handler which has been panicked
+	Panics uint64
+
+	// Lag is the duration between the bucket expiration and the time it been flushed
+	// by the event loop, at the last flush.
+	Lag time.Duration
+}
+
+// LayerStats is the snapshot of single layer wheel
+type LayerStats struct {
+	// Tick is the duration of every bucket in the layer
+	Tick time.Duration
+
+	// Interval is the duration of all buckets in the layer
+	Interval time.Duration
+
+	// WheelSize is the count of bucket in the layer
+	WheelSize int
+
+	// Occupied is the count of bucket which has any timertask
+	Occupied int
+
+	// Timers is the count of timertask in the layer
+	Timers int
+}
+
+// counters is the statistic counters of timingWheel, all fields is accessed atomically
+type counters struct {
+	fired   uint64
+	stopped uint64
+	panics  uint64
+
+	// lag is the last lag in nanoseconds
+	lag int64
+}
+
+// countExecutor wraps the Executor to count the fired Handler
+type countExecutor struct {
+	e Executor
+	c *counters
+}
+
+func (e countExecutor) Execute(f Handler, t time.Time) {
+	atomic.AddUint64(&e.c.fired, 1)
+	e.e.Execute(f, t)
+}
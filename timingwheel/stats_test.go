@@ -0,0 +1,57 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type countExecutorTestSuite struct {
+	suite.Suite
+
+	c *counters
+	e countExecutor
+}
+
+func (s *countExecutorTestSuite) SetupTest() {
+	s.c = &counters{}
+	s.e = countExecutor{e: ExecutorFunc(blockExecutor), c: s.c}
+}
+
+func (s *countExecutorTestSuite) TestExecute() {
+	now := time.Now()
+	var got time.Time
+	s.e.Execute(func(t time.Time) {
+		got = t
+	}, now)
+	s.Equal(now, got)
+	s.Equal(uint64(1), s.c.fired)
+}
+
+func TestCountExecutorTestSuite(t *testing.T) {
+	s := &countExecutorTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,205 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lsytj0413/ena/internal/wal"
+)
+
+// Record is the persistent representation of timertask
+type Record struct {
+	// Key is the stable identify of the timertask, it's unique in the Store
+	Key string `json:"key"`
+
+	// Deadline is the time when the timertask should been fired
+	Deadline time.Time `json:"deadline"`
+
+	// Payload is the user data which will been passed to the PersistentHandler
+	Payload []byte `json:"payload,omitempty"`
+}
+
+// Store is the interface to persist the timertask, the implementation must be goroutine-safe.
+type Store interface {
+	// Save insert or update the record with same key
+	Save(r Record) error
+
+	// Delete remove the record with the key, it's not an error if the key doesn't exist
+	Delete(key string) error
+
+	// Load returns all the records in the Store
+	Load() ([]Record, error)
+
+	// Close the Store, it can't been used after closed
+	Close() error
+}
+
+// logOp is the operation type of fileStore log entry
+type logOp = string
+
+const (
+	logOpSave   logOp = "save"
+	logOpDelete logOp = "delete"
+)
+
+// fileStoreCompactThreshold is the count of log entry to trigger the compaction, the log will been
+// compacted when the count of entry exceed the threshold and twice of the record count.
+const fileStoreCompactThreshold = 1024
+
+// logEntry is the single line in the fileStore log
+type logEntry struct {
+	Op logOp `json:"op"`
+	Record
+}
+
+// fileStore is the Store implementation backed by an append-only wal.Log,
+// every Save/Delete append one json line into the file and sync it.
+type fileStore struct {
+	// protect the log and records
+	mu sync.Mutex
+
+	// log is nil after closed
+	log *wal.Log
+
+	// records is the current records replayed from the log
+	records map[string]Record
+
+	// threshold is the count of log entry to trigger the compaction
+	threshold int
+}
+
+// NewFileStore creates an Store with the append-only log file at path, the file will been
+// created if it doesn't exist. The exists log is replayed and compacted when opened, and it's
+// compacted again when the count of log entry exceed the fileStoreCompactThreshold.
+func NewFileStore(path string) (Store, error) {
+	s := &fileStore{
+		records:   make(map[string]Record),
+		threshold: fileStoreCompactThreshold,
+	}
+
+	log, err := wal.Open(path, func(data []byte) error {
+		var e logEntry
+		if err := json.Unmarshal(data, &e); err != nil {
+			return err
+		}
+
+		switch e.Op {
+		case logOpSave:
+			s.records[e.Key] = e.Record
+		case logOpDelete:
+			delete(s.records, e.Key)
+		default:
+			return fmt.Errorf("InvalidLogOp: Op[%s]", e.Op)
+		}
+		return nil
+	}, s.entries)
+	if err != nil {
+		return nil, fmt.Errorf("FileStore: %w", err)
+	}
+	s.log = log
+	return s, nil
+}
+
+// entries returns the log entries of current records
+func (s *fileStore) entries() []interface{} {
+	entries := make([]interface{}, 0, len(s.records))
+	for _, r := range s.records {
+		entries = append(entries, logEntry{Op: logOpSave, Record: r})
+	}
+	return entries
+}
+
+// append the entry into the log file and sync it
+func (s *fileStore) append(e logEntry) error {
+	if s.log == nil {
+		return ErrStoreClosed
+	}
+
+	return s.log.Append(e)
+}
+
+// compact the log with the current records if the count of entry exceed the threshold
+func (s *fileStore) compact() error {
+	if !s.log.Compactable(len(s.records), s.threshold) {
+		return nil
+	}
+	return s.log.Compact(s.entries())
+}
+
+func (s *fileStore) Save(r Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(logEntry{Op: logOpSave, Record: r}); err != nil {
+		return err
+	}
+	s.records[r.Key] = r
+	return s.compact()
+}
+
+func (s *fileStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[key]; !ok {
+		return nil
+	}
+
+	if err := s.append(logEntry{Op: logOpDelete, Record: Record{Key: key}}); err != nil {
+		return err
+	}
+	delete(s.records, key)
+	return s.compact()
+}
+
+func (s *fileStore) Load() ([]Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log == nil {
+		return nil, ErrStoreClosed
+	}
+
+	records := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+func (s *fileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.log == nil {
+		return ErrStoreClosed
+	}
+
+	err := s.log.Close()
+	s.log = nil
+	return err
+}
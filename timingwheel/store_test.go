@@ -0,0 +1,169 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type fileStoreTestSuite struct {
+	suite.Suite
+
+	path string
+}
+
+func (s *fileStoreTestSuite) SetupTest() {
+	s.path = filepath.Join(s.T().TempDir(), "timers.log")
+}
+
+func (s *fileStoreTestSuite) load(st Store) []Record {
+	records, err := st.Load()
+	s.NoError(err)
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Key < records[j].Key
+	})
+	return records
+}
+
+func (s *fileStoreTestSuite) lines() int {
+	data, err := os.ReadFile(s.path)
+	s.NoError(err)
+	return bytes.Count(data, []byte("\n"))
+}
+
+func (s *fileStoreTestSuite) TestSaveDelete() {
+	deadline := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	st, err := NewFileStore(s.path)
+	s.NoError(err)
+	s.NoError(st.Save(Record{Key: "a", Deadline: deadline, Payload: []byte("a")}))
+	s.NoError(st.Save(Record{Key: "b", Deadline: deadline}))
+	s.NoError(st.Save(Record{Key: "c", Deadline: deadline}))
+	s.NoError(st.Save(Record{Key: "b", Deadline: deadline.Add(time.Second), Payload: []byte("b")}))
+	s.NoError(st.Delete("c"))
+	s.NoError(st.Delete("d"))
+
+	expect := []Record{
+		{Key: "a", Deadline: deadline, Payload: []byte("a")},
+		{Key: "b", Deadline: deadline.Add(time.Second), Payload: []byte("b")},
+	}
+	s.Equal(expect, s.load(st))
+	s.NoError(st.Close())
+	s.Equal(5, s.lines())
+
+	// the log is replayed and compacted when reopen
+	st, err = NewFileStore(s.path)
+	s.NoError(err)
+	defer st.Close()
+	s.Equal(2, s.lines())
+
+	records := s.load(st)
+	s.Equal(len(expect), len(records))
+	for i := range expect {
+		s.Equal(expect[i].Key, records[i].Key)
+		s.True(expect[i].Deadline.Equal(records[i].Deadline))
+		s.Equal(expect[i].Payload, records[i].Payload)
+	}
+}
+
+func (s *fileStoreTestSuite) TestCompact() {
+	deadline := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	st, err := NewFileStore(s.path)
+	s.NoError(err)
+	defer st.Close()
+	st.(*fileStore).threshold = 4
+
+	s.NoError(st.Save(Record{Key: "a", Deadline: deadline}))
+	s.NoError(st.Save(Record{Key: "b", Deadline: deadline}))
+	s.NoError(st.Save(Record{Key: "a", Deadline: deadline.Add(time.Second)}))
+	s.Equal(3, s.lines())
+
+	// the log is compacted at the threshold
+	s.NoError(st.Delete("b"))
+	s.Equal(1, s.lines())
+
+	// the log is appended after compacted
+	s.NoError(st.Save(Record{Key: "c", Deadline: deadline}))
+	s.Equal(2, s.lines())
+	s.Equal([]string{"a", "c"}, func() []string {
+		ret := []string{}
+		for _, r := range s.load(st) {
+			ret = append(ret, r.Key)
+		}
+		return ret
+	}())
+}
+
+func (s *fileStoreTestSuite) TestPartialEntry() {
+	s.NoError(os.WriteFile(s.path, []byte(`{"op":"save","key":"a","deadline":"2019-01-01T00:00:00Z"}
+{"op":"save","key":"b","dead`), 0644))
+
+	st, err := NewFileStore(s.path)
+	s.NoError(err)
+	defer st.Close()
+	s.Equal(1, s.lines())
+	s.Equal([]string{"a"}, func() []string {
+		ret := []string{}
+		for _, r := range s.load(st) {
+			ret = append(ret, r.Key)
+		}
+		return ret
+	}())
+}
+
+func (s *fileStoreTestSuite) TestInvalidEntry() {
+	s.NoError(os.WriteFile(s.path, []byte(`{"op":"save","key":"a","dead
+{"op":"save","key":"b","deadline":"2019-01-01T00:00:00Z"}
+`), 0644))
+	_, err := NewFileStore(s.path)
+	s.Error(err)
+
+	s.NoError(os.WriteFile(s.path, []byte(`{"op":"update","key":"a","deadline":"2019-01-01T00:00:00Z"}
+`), 0644))
+	_, err = NewFileStore(s.path)
+	s.Error(err)
+}
+
+func (s *fileStoreTestSuite) TestClosed() {
+	st, err := NewFileStore(s.path)
+	s.NoError(err)
+	s.NoError(st.Close())
+
+	s.Equal(ErrStoreClosed, st.Save(Record{Key: "a"}))
+	_, err = st.Load()
+	s.Equal(ErrStoreClosed, err)
+	s.Equal(ErrStoreClosed, st.Close())
+}
+
+func TestFileStoreTestSuite(t *testing.T) {
+	s := &fileStoreTestSuite{}
+	suite.Run(t, s)
+}
@@ -0,0 +1,129 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"math/rand"
+	"time"
+)
+
+// TickPolicy is the policy to calculate the next fire time of TickFunc timertask
+type TickPolicy int
+
+const (
+	// TickFixedDelay fire the timertask at the duration after the last fired time,
+	// so the timertask will drift by the latency of dispatch.
+	TickFixedDelay TickPolicy = iota
+
+	// TickFixedRate fire the timertask at the multiple of duration after the first
+	// fire time, so the timertask will not drift.
+	TickFixedRate
+)
+
+// MissedTickPolicy is the policy to deal with the missed ticks of TickFixedRate timertask,
+// such as the event loop is paused for a long time.
+type MissedTickPolicy int
+
+const (
+	// MissedTickSkip skip the missed ticks, the timertask will be fired at the next tick
+	// after now.
+	MissedTickSkip MissedTickPolicy = iota
+
+	// MissedTickCatchUp fire the timertask once for every missed tick immediately.
+	MissedTickCatchUp
+)
+
+// tickOption is the configuration of TickFunc timertask
+type tickOption struct {
+	// Policy is the TickPolicy, default is TickFixedDelay
+	Policy TickPolicy
+
+	// Missed is the MissedTickPolicy, default is MissedTickSkip
+	Missed MissedTickPolicy
+
+	// Jitter is the max random duration add to every fire time, default is zero
+	Jitter time.Duration
+}
+
+// Validate check the tickOption
+func (o *tickOption) Validate() error {
+	switch o.Policy {
+	case TickFixedDelay, TickFixedRate:
+	default:
+		return ErrInvalidTickPolicy
+	}
+
+	switch o.Missed {
+	case MissedTickSkip, MissedTickCatchUp:
+	default:
+		return ErrInvalidTickPolicy
+	}
+
+	if o.Jitter < 0 {
+		return ErrInvalidJitter
+	}
+	return nil
+}
+
+// jitter returns the random duration in [0, Jitter)
+func (o *tickOption) jitter() time.Duration {
+	if o.Jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(o.Jitter)))
+}
+
+// TickOption is some configuration that modifies options for a TickFunc timertask.
+type TickOption interface {
+	ApplyTick(*tickOption)
+}
+
+// tickOptionFunc is an adapter to allow the use of ordinary functions as TickOption
+type tickOptionFunc func(*tickOption)
+
+// ApplyTick applies this configuration to the given option
+func (f tickOptionFunc) ApplyTick(opt *tickOption) {
+	f(opt)
+}
+
+// WithTickPolicy set the Policy field
+func WithTickPolicy(p TickPolicy) TickOption {
+	return tickOptionFunc(func(opt *tickOption) {
+		opt.Policy = p
+	})
+}
+
+// WithMissedTickPolicy set the Missed field, it only works with TickFixedRate
+func WithMissedTickPolicy(p MissedTickPolicy) TickOption {
+	return tickOptionFunc(func(opt *tickOption) {
+		opt.Missed = p
+	})
+}
+
+// WithJitter set the Jitter field, every fire time will be delayed by a random duration
+// in [0, d), it's useful to spread the timertask with same duration.
+func WithJitter(d time.Duration) TickOption {
+	return tickOptionFunc(func(opt *tickOption) {
+		opt.Jitter = d
+	})
+}
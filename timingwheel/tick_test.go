@@ -0,0 +1,102 @@
+// MIT License
+
+// Copyright (c) 2019 soren yang
+
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+// SOFTWARE.
+
+package timingwheel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type tickOptionTestSuite struct {
+	suite.Suite
+}
+
+func (s *tickOptionTestSuite) TestApply() {
+	o := tickOption{}
+	for _, opt := range []TickOption{
+		WithTickPolicy(TickFixedRate),
+		WithMissedTickPolicy(MissedTickCatchUp),
+		WithJitter(time.Second),
+	} {
+		opt.ApplyTick(&o)
+	}
+	s.Equal(tickOption{
+		Policy: TickFixedRate,
+		Missed: MissedTickCatchUp,
+		Jitter: time.Second,
+	}, o)
+}
+
+func (s *tickOptionTestSuite) TestValidate() {
+	type testCase struct {
+		description string
+		o           tickOption
+		expect      error
+	}
+	testCases := []testCase{
+		{
+			description: "default option",
+			o:           tickOption{},
+			expect:      nil,
+		},
+		{
+			description: "invalid policy",
+			o:           tickOption{Policy: TickPolicy(-1)},
+			expect:      ErrInvalidTickPolicy,
+		},
+		{
+			description: "invalid missed policy",
+			o:           tickOption{Missed: MissedTickPolicy(2)},
+			expect:      ErrInvalidTickPolicy,
+		},
+		{
+			description: "invalid jitter",
+			o:           tickOption{Jitter: -time.Millisecond},
+			expect:      ErrInvalidJitter,
+		},
+	}
+	for _, tc := range testCases {
+		s.Run(tc.description, func() {
+			s.Equal(tc.expect, tc.o.Validate())
+		})
+	}
+}
+
+func (s *tickOptionTestSuite) TestJitter() {
+	o := tickOption{}
+	s.Equal(time.Duration(0), o.jitter())
+
+	o.Jitter = time.Millisecond
+	for i := 0; i < 100; i++ {
+		v := o.jitter()
+		s.GreaterOrEqual(v, time.Duration(0))
+		s.Less(v, time.Millisecond)
+	}
+}
+
+func TestTickOptionTestSuite(t *testing.T) {
+	s := &tickOptionTestSuite{}
+	suite.Run(t, s)
+}
@@ -28,9 +28,10 @@ import (
 	"time"
 )
 
-// stopWheel is wrap for timingWheel.StopFunc, testable
+// stopWheel is wrap for timingWheel.StopFunc and timingWheel.ResetFunc, testable
 type stopWheel interface {
 	StopFunc(t *timerTask) (bool, error)
+	ResetFunc(t *timerTask, d time.Duration) (bool, error)
 }
 
 // timerTask represent single task. When expires, the given
@@ -38,7 +39,8 @@ type stopWheel interface {
 type timerTask struct {
 	// d is the duration of timertask
 	d time.Duration
-	// expiration of the task
+	// expiration of the task, it's only changed by the event loop, and
+	// accessed atomically when outside the event loop
 	expiration int64
 	// the timer type, when the type is Tick, the timer will reinsert into the
 	// wheel after fired.
@@ -50,6 +52,26 @@ type timerTask struct {
 	// task handler
 	f Handler
 
+	// s is the Schedule of the timertask when the type is Schedule
+	s Schedule
+
+	// u is the time unit of expiration
+	u time.Duration
+
+	// g is the group which the timertask belongs to, maybe nil
+	g *group
+
+	// pending is true before the timertask add into the wheel by the event loop
+	pending bool
+
+	// key and payload is the Record field when the timertask is persistent
+	key     string
+	payload []byte
+
+	// next is the fire time without jitter, and o is the option when the type is Tick
+	next time.Time
+	o    tickOption
+
 	// sign of whether the timertask has stopped,
 	// 1: stopped
 	// 0: non stopped
@@ -76,3 +98,72 @@ func (t *timerTask) Stop() (bool, error) {
 
 	return stopped || atomic.LoadUint32(&t.stopped) == 1, nil
 }
+
+// ID returns the unique id of the timer task
+func (t *timerTask) ID() uint64 {
+	return t.id
+}
+
+// Deadline returns the time when the timer task will been fired, it's in the time unit precision.
+// After the timer task expired or been stopped, it returns the last deadline.
+func (t *timerTask) Deadline() time.Time {
+	return unitToTime(atomic.LoadInt64(&t.expiration), t.u)
+}
+
+// Reset the timer task to expire after duration d, return true if the timer had been active,
+// or false if the timer had expired or been stopped.
+func (t *timerTask) Reset(d time.Duration) (bool, error) {
+	return t.w.ResetFunc(t, d)
+}
+
+// setNext updates the fire time of the timertask, the jitter will been add to the expiration.
+func (t *timerTask) setNext(next time.Time) {
+	t.next = next
+	atomic.StoreInt64(&t.expiration, timeToUnit(next.Add(t.o.jitter()), t.u))
+}
+
+// reschedule updates the expiration of repetitious timertask after fired at now,
+// it returns false if the timertask shouldn't been reinsert into the wheel.
+// The earliest is the min expiration in the time unit witch won't been treat as expired by the wheel.
+func (t *timerTask) reschedule(now time.Time, earliest int64) bool {
+	if atomic.LoadUint32(&t.stopped) == 1 {
+		return false
+	}
+
+	switch t.t {
+	case taskTick:
+		switch t.o.Policy {
+		case TickFixedRate:
+			next := t.next.Add(t.d)
+			if t.o.Missed == MissedTickSkip && next.Before(now) {
+				next = next.Add((now.Sub(next)/t.d + 1) * t.d)
+			}
+			t.setNext(next)
+		default:
+			t.setNext(now.Add(t.d))
+		}
+		return true
+	case taskSchedule:
+		// the timertask maybe fired before the expiration within one tick, so we calculate
+		// the next fire time from the later one to avoid fire twice.
+		base := unitToTime(t.expiration, t.u)
+		if now.After(base) {
+			base = now
+		}
+
+		next := t.s.Next(base)
+		if next.IsZero() {
+			return false
+		}
+
+		// the next fire time within current tick will been fired immediately again,
+		// so we delay it to the next tick.
+		expiration := timeToUnit(next, t.u)
+		if expiration < earliest {
+			expiration = earliest
+		}
+		atomic.StoreInt64(&t.expiration, expiration)
+		return true
+	}
+	return false
+}
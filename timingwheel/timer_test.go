@@ -25,6 +25,7 @@ package timingwheel
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -43,6 +44,11 @@ func (m *mockStopWheel) StopFunc(t *timerTask) (bool, error) {
 	return args.Get(0).(bool), args.Error(1)
 }
 
+func (m *mockStopWheel) ResetFunc(t *timerTask, d time.Duration) (bool, error) {
+	args := m.Called(t, d)
+	return args.Get(0).(bool), args.Error(1)
+}
+
 type timerTaskTestSuite struct {
 	suite.Suite
 
@@ -51,7 +57,7 @@ type timerTaskTestSuite struct {
 }
 
 func (s *timerTaskTestSuite) SetupTest() {
-	s.t = &timerTask{}
+	s.t = &timerTask{u: time.Millisecond}
 	s.w = &mockStopWheel{}
 	s.t.w = s.w
 }
@@ -87,6 +93,107 @@ func (s *timerTaskTestSuite) TestStopThenStopped() {
 	s.Equal(s.t.stopped, uint32(1))
 }
 
+func (s *timerTaskTestSuite) TestIDAndDeadline() {
+	s.t.id = 10
+	s.t.expiration = timeToUnit(time.Date(2019, 1, 1, 0, 0, 1, 0, time.UTC), time.Millisecond)
+	s.Equal(uint64(10), s.t.ID())
+	s.True(time.Date(2019, 1, 1, 0, 0, 1, 0, time.UTC).Equal(s.t.Deadline()))
+}
+
+func (s *timerTaskTestSuite) TestReset() {
+	s.w.On("ResetFunc", s.t, time.Second).Return(true, nil)
+	v, err := s.t.Reset(time.Second)
+	s.True(v)
+	s.NoError(err)
+
+	merr := fmt.Errorf("MockFailed")
+	s.w.On("ResetFunc", s.t, time.Minute).Return(false, merr)
+	v, err = s.t.Reset(time.Minute)
+	s.False(v)
+	s.Equal(merr, err)
+}
+
+func (s *timerTaskTestSuite) TestReschedule() {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s.Run("after task", func() {
+		s.t.t = taskAfter
+		s.False(s.t.reschedule(now, 0))
+	})
+
+	s.Run("tick task", func() {
+		s.t.t = taskTick
+		s.t.d = time.Second
+		s.True(s.t.reschedule(now, 0))
+		s.Equal(timeToUnit(now.Add(time.Second), time.Millisecond), s.t.expiration)
+	})
+
+	s.Run("fixed rate tick task", func() {
+		s.t.o = tickOption{Policy: TickFixedRate}
+		s.t.next = now
+		s.True(s.t.reschedule(now.Add(100*time.Millisecond), 0))
+		s.Equal(now.Add(time.Second), s.t.next)
+		s.Equal(timeToUnit(now.Add(time.Second), time.Millisecond), s.t.expiration)
+	})
+
+	s.Run("fixed rate tick task skip missed", func() {
+		s.t.next = now
+		s.True(s.t.reschedule(now.Add(3500*time.Millisecond), 0))
+		s.Equal(now.Add(4*time.Second), s.t.next)
+	})
+
+	s.Run("fixed rate tick task catch up missed", func() {
+		s.t.o = tickOption{Policy: TickFixedRate, Missed: MissedTickCatchUp}
+		s.t.next = now
+		s.True(s.t.reschedule(now.Add(3500*time.Millisecond), 0))
+		s.Equal(now.Add(time.Second), s.t.next)
+	})
+
+	s.Run("tick task with jitter", func() {
+		s.t.o = tickOption{Policy: TickFixedRate, Jitter: 500 * time.Millisecond}
+		for i := 0; i < 10; i++ {
+			s.t.next = now
+			s.True(s.t.reschedule(now, 0))
+			s.Equal(now.Add(time.Second), s.t.next)
+			s.GreaterOrEqual(s.t.expiration, timeToUnit(now.Add(time.Second), time.Millisecond))
+			s.Less(s.t.expiration, timeToUnit(now.Add(1500*time.Millisecond), time.Millisecond))
+		}
+		s.t.o = tickOption{}
+	})
+
+	s.Run("schedule task fired early", func() {
+		s.t.t = taskSchedule
+		s.t.s = Every(time.Minute)
+		s.t.expiration = timeToUnit(now.Add(time.Second), time.Millisecond)
+		s.True(s.t.reschedule(now, 0))
+		s.Equal(timeToUnit(now.Add(time.Minute+time.Second), time.Millisecond), s.t.expiration)
+	})
+
+	s.Run("schedule task fired late", func() {
+		s.t.expiration = timeToUnit(now, time.Millisecond)
+		s.True(s.t.reschedule(now.Add(time.Second), 0))
+		s.Equal(timeToUnit(now.Add(time.Minute+time.Second), time.Millisecond), s.t.expiration)
+	})
+
+	s.Run("schedule task within one tick", func() {
+		s.t.s = Every(time.Millisecond)
+		s.t.expiration = timeToUnit(now, time.Millisecond)
+		s.True(s.t.reschedule(now, timeToUnit(now.Add(10*time.Millisecond), time.Millisecond)))
+		s.Equal(timeToUnit(now.Add(10*time.Millisecond), time.Millisecond), s.t.expiration)
+	})
+
+	s.Run("schedule task without next", func() {
+		s.t.s = Every(0)
+		s.False(s.t.reschedule(now, 0))
+	})
+
+	s.Run("stopped task", func() {
+		s.t.t = taskTick
+		s.t.stopped = 1
+		s.False(s.t.reschedule(now, 0))
+	})
+}
+
 func TestTimerTaskTestSuite(t *testing.T) {
 	s := &timerTaskTestSuite{}
 	suite.Run(t, s)
@@ -25,9 +25,11 @@ package timingwheel
 
 import (
 	"context"
+	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/lsytj0413/ena/clock"
 	"github.com/lsytj0413/ena/conc"
 	"github.com/lsytj0413/ena/conc/wait"
 	"github.com/lsytj0413/ena/delayqueue"
@@ -37,8 +39,38 @@ type option struct {
 	// Tick is the tick duration with wheel
 	Tick time.Duration
 
+	// Unit is the time unit of the wheel, the Tick must be greater than or equal to it
+	Unit time.Duration
+
 	// WheelSize is the size of buckets
 	WheelSize int64
+
+	// Executor is used to run the Handler when timertask is fired
+	Executor Executor
+
+	// Clock is used to provide the current time and wait for the bucket expiration
+	Clock clock.Clock
+
+	// PanicHandler is called when the Handler panics
+	PanicHandler PanicHandler
+
+	// ErrorHandler is called when the ContextHandler returns error
+	ErrorHandler ErrorHandler
+
+	// Store is used to persist the timer, and PersistentHandler is called when it fired
+	Store             Store
+	PersistentHandler PersistentHandler
+
+	// RecoverPolicy is used to deal with the overdue persistent timer when recover
+	RecoverPolicy RecoverPolicy
+
+	// DelayQueue is the queue of bucket expiration, the default is the in-memory DelayQueue
+	DelayQueue delayqueue.DelayQueue
+
+	// shard is the index of the wheel in the ShardedTimingWheel, and shards is the count
+	// of wheels, it's zero when the wheel isn't sharded.
+	shard  uint64
+	shards uint64
 }
 
 // Validate check the option
@@ -67,52 +99,201 @@ func (w WithSize) Apply(opt *option) {
 	opt.WheelSize = int64(w)
 }
 
+// optionFunc is an adapter to allow the use of ordinary functions as Option
+type optionFunc func(*option)
+
+// Apply applies this configuration to the given option
+func (f optionFunc) Apply(opt *option) {
+	f(opt)
+}
+
+// WithTimeUnit set the Unit field, the default is time.Millisecond. The Tick below
+// millisecond can be used with time.Microsecond or time.Nanosecond.
+func WithTimeUnit(u time.Duration) Option {
+	return optionFunc(func(opt *option) {
+		opt.Unit = u
+	})
+}
+
+// WithExecutor set the Executor field, the default Executor will run every Handler
+// in its own goroutine.
+func WithExecutor(e Executor) Option {
+	return optionFunc(func(opt *option) {
+		opt.Executor = e
+	})
+}
+
+// WithClock set the Clock field, the default Clock is backed by the time package.
+func WithClock(c clock.Clock) Option {
+	return optionFunc(func(opt *option) {
+		opt.Clock = c
+	})
+}
+
+// WithPanicHandler set the PanicHandler field, the panic of Handler is always recovered,
+// and the default PanicHandler does nothing.
+func WithPanicHandler(h PanicHandler) Option {
+	return optionFunc(func(opt *option) {
+		opt.PanicHandler = h
+	})
+}
+
+// WithErrorHandler set the ErrorHandler field, the default ErrorHandler does nothing.
+func WithErrorHandler(h ErrorHandler) Option {
+	return optionFunc(func(opt *option) {
+		opt.ErrorHandler = h
+	})
+}
+
+// WithStore set the Store and PersistentHandler field, the persistent timer in the Store will
+// been recovered when creates the TimingWheel. The Store will not been closed by the TimingWheel.
+func WithStore(s Store, h PersistentHandler) Option {
+	return optionFunc(func(opt *option) {
+		opt.Store = s
+		opt.PersistentHandler = h
+	})
+}
+
+// WithRecoverPolicy set the RecoverPolicy field, the default is RecoverFire
+func WithRecoverPolicy(p RecoverPolicy) Option {
+	return optionFunc(func(opt *option) {
+		opt.RecoverPolicy = p
+	})
+}
+
+// WithDelayQueue set the DelayQueue field, the DelayQueue must use the same Clock and time unit
+// with the timingwheel and deliver the offered element by itself, EX: the delayqueue.DurableQueue
+// keep the bucket in memory. It can't been used by the ShardedTimingWheel.
+// NOTE: the bucket is Volatile, so the timertask will NOT been persisted by the DurableQueue and
+// is lost after crash, use the WithStore to persist the timertask instead.
+func WithDelayQueue(dq delayqueue.DelayQueue) Option {
+	return optionFunc(func(opt *option) {
+		opt.DelayQueue = dq
+	})
+}
+
 // NewTimingWheel creates an instance of TimingWheel with the given tick and wheelSize.
 func NewTimingWheel(opts ...Option) (TimingWheel, error) {
 	options := &option{
 		Tick:      time.Second,
+		Unit:      time.Millisecond,
 		WheelSize: 64,
+		Executor:  defaultExecutor,
+		Clock:     clock.New(),
 	}
 	for _, opt := range opts {
 		opt.Apply(options)
 	}
 
-	tickMs := int64(options.Tick / time.Millisecond)
-	if tickMs <= 0 {
+	if options.Unit <= 0 {
+		return nil, ErrInvalidTimeUnit
+	}
+	tick := int64(options.Tick / options.Unit)
+	if tick <= 0 {
 		return nil, ErrInvalidTickValue
 	}
 	if options.WheelSize <= 0 {
 		return nil, ErrInvalidWheelSize
 	}
+	if options.Executor == nil {
+		return nil, ErrInvalidExecutor
+	}
+	if options.Clock == nil {
+		return nil, ErrInvalidClock
+	}
+	if options.Store != nil && options.PersistentHandler == nil {
+		return nil, ErrInvalidPersistentHandler
+	}
+	switch options.RecoverPolicy {
+	case RecoverFire, RecoverDrop:
+	default:
+		return nil, ErrInvalidRecoverPolicy
+	}
+	if options.DelayQueue != nil && options.shards > 1 {
+		// the wheels can't share the DelayQueue
+		return nil, ErrInvalidDelayQueue
+	}
+	if options.DelayQueue == nil {
+		options.DelayQueue = delayqueue.NewWithUnit(int(options.WheelSize), options.Clock, options.Unit)
+	}
 
-	startMs := timeToMs(time.Now())
-	t := newWheel(tickMs, options.WheelSize, startMs)
+	start := timeToUnit(options.Clock.Now(), options.Unit)
+	t := newWheel(tick, options.WheelSize, start)
 
 	tw := &timingWheel{
-		dq:  delayqueue.New(int(options.WheelSize)),
-		w:   t,
-		wt:  wait.New(),
-		wch: make(chan event, int(options.WheelSize)*100), // the channel is bufferd, could change to unbufferd?
+		dq:   options.DelayQueue,
+		w:    t,
+		u:    options.Unit,
+		e:    newTrackedExecutor(options.Executor),
+		c:    options.Clock,
+		wt:   wait.New(),
+		q:    newEventQueue(),
+		sch:  make(chan shutdownRequest),
+		done: make(chan struct{}),
+		ph:   options.PanicHandler,
+		eh:   options.ErrorHandler,
+		s:    options.Store,
+		sh:   options.PersistentHandler,
+		pm:   make(map[string]*timerTask),
+		sid:  options.shard << shardIDShift,
+		own: func(key string) bool {
+			return options.shards <= 1 || shardOf(key, options.shards) == options.shard
+		},
 	}
 
+	tw.ce = countExecutor{e: tw.e, c: &tw.cnt}
 	tw.ctx, tw.cancel = context.WithCancel(context.Background())
+
+	if tw.s != nil {
+		if err := tw.recover(options.RecoverPolicy); err != nil {
+			return nil, err
+		}
+	}
 	return tw, nil
 }
 
 // timingWheel is an implemention of TimingWheel
-// TODO(lsytj0413): disable add/tick/stopfunc when not running?
 type timingWheel struct {
 	// the first layer wheel
 	w *wheel
 
-	// the Wait to get response when call AfterFunc
+	// u is the time unit of the wheel
+	u time.Duration
+
+	// e is the Executor to run the fired timertask
+	e TrackedExecutor
+
+	// c is the Clock to provide the current time
+	c clock.Clock
+
+	// ph and eh is called when the Handler panics or returns error, maybe nil
+	ph PanicHandler
+	eh ErrorHandler
+
+	// s is the Store of persistent timertask, maybe nil, and sh is called when it fired
+	s  Store
+	sh PersistentHandler
+
+	// pm is the persistent timertask with the key, protected by pmu
+	pmu sync.Mutex
+	pm  map[string]*timerTask
+
+	// the Wait to get response when call StopFunc
 	wt wait.Wait
 
 	// the Wait register id, incr
 	wid uint64
 
-	// wch is the channel which TimerTask putin when call AfterFunc
-	wch chan event
+	// sid is the prefix of timertask id when the wheel is sharded, and own returns whether
+	// the persistent timertask with key belongs to the wheel.
+	sid uint64
+	own func(key string) bool
+
+	// q is the queue which TimerTask putin when call AfterFunc/StopFunc
+	q *eventQueue
+
+	// sch is the channel which shutdown request putin when call Shutdown
+	sch chan shutdownRequest
 
 	// dq is the queue of bucket expiration
 	dq delayqueue.DelayQueue
@@ -123,54 +304,85 @@ type timingWheel struct {
 	// ctx to cancel sub goroutine
 	ctx    context.Context
 	cancel func()
+
+	// startOnce make sure the sub goroutine only started once
+	startOnce sync.Once
+
+	// mu protect the stopped, the enqueue of q will hold the read lock,
+	// so there is no event will been put into q after stopped.
+	mu      sync.RWMutex
+	stopped bool
+
+	// done will been closed after shutdown complete
+	done chan struct{}
+
+	// started is protected by mu, the wheel can only been accessed by the event loop after started
+	started bool
+
+	// cnt is the statistic counters, and ce is the Executor wrap e to count the Handler
+	cnt counters
+	ce  Executor
 }
 
-// event is the representation of value in the wch
+// event is the representation of value in the q
 type event struct {
 	// the identify of the event
 	Type eventType
 
 	t *timerTask
+
+	// rid is the Wait register id when the event need response
+	rid uint64
+
+	// d and next is the new duration and fire time of the timertask when Reset
+	d    time.Duration
+	next time.Time
+
+	// g is the group when StopAll
+	g *group
+}
+
+// shutdownRequest is the representation of value in the sch
+type shutdownRequest struct {
+	ctx  context.Context
+	mode ShutdownMode
+
+	// done will been closed after the event loop exited
+	done chan struct{}
+}
+
+func (tw *timingWheel) Start(ctx context.Context) {
+	tw.startOnce.Do(tw.start)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			tw.Stop()
+		case <-tw.done:
+		}
+	}()
 }
 
-func (tw *timingWheel) Start() {
+func (tw *timingWheel) start() {
+	tw.mu.Lock()
+	tw.started = true
+	tw.mu.Unlock()
+
 	tw.wg.Wrap(func() {
 		tw.dq.Poll(tw.ctx)
 	})
 
-	addOrRun := func(t *timerTask) {
-		tw.w.addOrRun(t, tw.dq)
-	}
-
 	tw.wg.Wrap(func() {
 		for {
 			select {
 			case elem := <-tw.dq.Chan():
-				b := elem.(*bucket)
-				tw.w.advanceClock(b.Expiration())
-
-				b.Flush(addOrRun)
-			case e := <-tw.wch:
-				switch e.Type {
-				case eventAddNew:
-					// an timer task is add from AfterFunc/StopFunc
-					addOrRun(e.t)
-					_ = tw.wt.Trigger(e.t.id, e.t)
-				case eventDelete:
-					stopped := false
-					switch atomic.LoadUint32(&e.t.stopped) {
-					case 1:
-						stopped = true
-					default:
-						if e.t.b != nil {
-							stopped = e.t.b.remove(e.t)
-						}
-						if stopped {
-							atomic.StoreUint32(&e.t.stopped, 1)
-						}
-					}
-					_ = tw.wt.Trigger(e.t.id, stopped)
-				}
+				tw.flush(elem.(*bucket))
+			case <-tw.q.C():
+				tw.q.Take(tw.handle)
+			case req := <-tw.sch:
+				tw.drain(req)
+				close(req.done)
+				return
 			case <-tw.ctx.Done():
 				return
 			}
@@ -178,64 +390,592 @@ func (tw *timingWheel) Start() {
 	})
 }
 
-// TODO(lsytj0413): clear all timer after stop?
+func (tw *timingWheel) addOrRun(t *timerTask) {
+	tw.w.addOrRun(t, tw.dq, tw.ce, tw.c)
+}
+
+// flush the bucket which is expired, all the timertask in the bucket will be
+// executed or reinsert into the lower layer.
+func (tw *timingWheel) flush(b *bucket) {
+	lag := tw.c.Now().Sub(unitToTime(b.Expiration(), tw.u))
+	atomic.StoreInt64(&tw.cnt.lag, int64(lag))
+
+	tw.w.advanceClock(b.Expiration())
+	b.Flush(tw.addOrRun)
+}
+
+// handle the event from q
+func (tw *timingWheel) handle(e event) {
+	switch e.Type {
+	case eventAddNew:
+		// an timer task is add from AfterFunc/TickFunc
+		if e.t.g != nil && e.t.g.isStopped() {
+			// the group is stopped before the timertask add into the wheel, it's still
+			// pending and will been count by the StopAll
+			atomic.StoreUint32(&e.t.stopped, 1)
+			return
+		}
+		if atomic.LoadUint32(&e.t.stopped) == 1 {
+			// the timertask is stopped before the event loop started
+			return
+		}
+		e.t.pending = false
+		tw.addOrRun(e.t)
+	case eventDelete:
+		stopped := false
+		switch atomic.LoadUint32(&e.t.stopped) {
+		case 1:
+			stopped = true
+		default:
+			if e.t.pending {
+				// the timertask isn't added into the wheel before the event loop started
+				stopped = true
+			} else if e.t.b != nil {
+				stopped = e.t.b.remove(e.t)
+			}
+			if stopped {
+				atomic.StoreUint32(&e.t.stopped, 1)
+				atomic.AddUint64(&tw.cnt.stopped, 1)
+			}
+		}
+		_ = tw.wt.Trigger(e.rid, stopped)
+	case eventReset:
+		active := false
+		if e.t.b != nil {
+			active = e.t.b.remove(e.t)
+		}
+		atomic.StoreUint32(&e.t.stopped, 0)
+
+		if e.t.t == taskTick {
+			// the tick timertask will use the new duration as interval
+			e.t.d = e.d
+		}
+		e.t.setNext(e.next)
+		tw.addOrRun(e.t)
+		_ = tw.wt.Trigger(e.rid, active)
+	case eventStats:
+		_ = tw.wt.Trigger(e.rid, tw.stats())
+	case eventStopGroup:
+		n := 0
+		for _, t := range e.g.drain() {
+			atomic.StoreUint32(&t.stopped, 1)
+			if t.pending || (t.b != nil && t.b.remove(t)) {
+				// the pending timertask will not been add into the wheel
+				n++
+			}
+		}
+		atomic.AddUint64(&tw.cnt.stopped, uint64(n))
+		_ = tw.wt.Trigger(e.rid, n)
+	}
+}
+
+// handlePending handle the event before the event loop started, all the timertask is still
+// pending in the q and will been added into the wheel after started.
+func (tw *timingWheel) handlePending(e event) {
+	switch e.Type {
+	case eventReset:
+		active := atomic.SwapUint32(&e.t.stopped, 0) == 0
+		if e.t.t == taskTick {
+			e.t.d = e.d
+		}
+		e.t.setNext(e.next)
+		_ = tw.wt.Trigger(e.rid, active)
+	default:
+		tw.handle(e)
+	}
+}
+
+// drain deal with the pending timertask by the shutdown mode, it's called in the
+// event loop after stopped, so there is no more event will been put into q.
+func (tw *timingWheel) drain(req shutdownRequest) {
+	tw.q.Take(tw.handle)
+
+	switch req.mode {
+	case ShutdownFire:
+		now := tw.c.Now()
+		tw.w.foreach(func(t *timerTask) {
+			t.b.remove(t)
+			tw.ce.Execute(t.f, now)
+		})
+	case ShutdownWait:
+		deadline, ok := req.ctx.Deadline()
+		tw.w.foreach(func(t *timerTask) {
+			if ok && t.expiration > timeToUnit(deadline, tw.u) {
+				t.b.remove(t)
+				atomic.StoreUint32(&t.stopped, 1)
+				return
+			}
+
+			// the tick timertask will only been fired once more
+			t.t = taskAfter
+		})
+
+		for tw.w.size() > 0 {
+			select {
+			case elem := <-tw.dq.Chan():
+				tw.flush(elem.(*bucket))
+				continue
+			case <-req.ctx.Done():
+			}
+			break
+		}
+	}
+
+	// drop all the remaining timertask
+	tw.w.foreach(func(t *timerTask) {
+		t.b.remove(t)
+		atomic.StoreUint32(&t.stopped, 1)
+	})
+}
+
 func (tw *timingWheel) Stop() {
+	_ = tw.shutdown(context.Background(), ShutdownDrop, false)
+}
+
+func (tw *timingWheel) Shutdown(ctx context.Context, mode ShutdownMode) error {
+	switch mode {
+	case ShutdownDrop, ShutdownFire, ShutdownWait:
+	default:
+		return ErrInvalidShutdownMode
+	}
+
+	return tw.shutdown(ctx, mode, true)
+}
+
+// shutdown stops the event loop with the mode, and wait for the running Handler if wait is true.
+func (tw *timingWheel) shutdown(ctx context.Context, mode ShutdownMode, wait bool) error {
+	// make sure the event loop is running, so the pending event can be handled
+	tw.startOnce.Do(tw.start)
+
+	tw.mu.Lock()
+	if tw.stopped {
+		tw.mu.Unlock()
+		<-tw.done
+		return ErrWheelStopped
+	}
+	tw.stopped = true
+	tw.mu.Unlock()
+
+	req := shutdownRequest{
+		ctx:  ctx,
+		mode: mode,
+		done: make(chan struct{}),
+	}
+	tw.sch <- req
+	<-req.done
+
 	tw.cancel()
 	tw.wg.Wait()
+	close(tw.done)
+
+	if !wait {
+		return nil
+	}
+	return tw.e.Wait(ctx)
 }
 
 func (tw *timingWheel) AfterFunc(d time.Duration, f Handler) (TimerTask, error) {
-	return tw.addFunc(d, f, taskAfter)
+	return tw.AfterContextFunc(d, contextHandler(f))
+}
+
+func (tw *timingWheel) AfterContextFunc(d time.Duration, f ContextHandler) (TimerTask, error) {
+	return tw.addFunc(d, f, taskAfter, tickOption{}, nil)
+}
+
+func (tw *timingWheel) TickFunc(d time.Duration, f Handler, opts ...TickOption) (TimerTask, error) {
+	return tw.TickContextFunc(d, contextHandler(f), opts...)
+}
+
+func (tw *timingWheel) TickContextFunc(d time.Duration, f ContextHandler, opts ...TickOption) (TimerTask, error) {
+	return tw.tickFunc(d, f, opts, nil)
+}
+
+func (tw *timingWheel) ScheduleFunc(s Schedule, f Handler) (TimerTask, error) {
+	return tw.ScheduleContextFunc(s, contextHandler(f))
+}
+
+func (tw *timingWheel) ScheduleContextFunc(s Schedule, f ContextHandler) (TimerTask, error) {
+	return tw.scheduleFunc(s, f, nil)
+}
+
+func (tw *timingWheel) NewGroup(ctx context.Context) Group {
+	return newGroup(ctx, tw)
 }
 
-func (tw *timingWheel) TickFunc(d time.Duration, f Handler) (TimerTask, error) {
-	v := d / (time.Duration(tw.w.tick) * time.Millisecond)
+func (tw *timingWheel) tickFunc(d time.Duration, f ContextHandler, opts []TickOption, g *group) (TimerTask, error) {
+	v := d / (time.Duration(tw.w.tick) * tw.u)
 	if v <= 0 {
 		return nil, ErrInvalidTickFuncDurationValue
 	}
 
-	return tw.addFunc(d, f, taskTick)
+	o := tickOption{
+		Policy: TickFixedDelay,
+		Missed: MissedTickSkip,
+	}
+	for _, opt := range opts {
+		opt.ApplyTick(&o)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	return tw.addFunc(d, f, taskTick, o, g)
+}
+
+func (tw *timingWheel) scheduleFunc(s Schedule, f ContextHandler, g *group) (TimerTask, error) {
+	next := s.Next(tw.c.Now())
+	if next.IsZero() {
+		return nil, ErrInvalidSchedule
+	}
+	if d, ok := s.(everySchedule); ok && time.Duration(d)/(time.Duration(tw.w.tick)*tw.u) <= 0 {
+		return nil, ErrInvalidTickFuncDurationValue
+	}
+
+	id := tw.nextID()
+	t := &timerTask{
+		expiration: timeToUnit(next, tw.u),
+		u:          tw.u,
+		t:          taskSchedule,
+		f:          tw.wrap(id, f),
+		s:          s,
+		id:         id,
+		w:          tw,
+		g:          g,
+	}
+	return tw.add(t)
 }
 
 func (tw *timingWheel) StopFunc(t *timerTask) (bool, error) {
-	outch, err := tw.enquenTask(t, eventDelete)
+	v, err := tw.request(event{
+		Type: eventDelete,
+		t:    t,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	stopped := v.(bool)
+	if stopped && t.key != "" {
+		tw.forget(t)
+	}
+	if stopped && t.g != nil {
+		t.g.leave(t)
+	}
+	return stopped, nil
+}
+
+func (tw *timingWheel) ResetFunc(t *timerTask, d time.Duration) (bool, error) {
+	if t.t == taskTick && d/(time.Duration(tw.w.tick)*tw.u) <= 0 {
+		return false, ErrInvalidTickFuncDurationValue
+	}
+
+	if t.g != nil {
+		// the timertask maybe leave the group after fired or stopped
+		if err := t.g.join(t); err != nil {
+			return false, err
+		}
+	}
+
+	next := tw.c.Now().Add(d)
+	v, err := tw.request(event{
+		Type: eventReset,
+		t:    t,
+		d:    d,
+		next: next,
+	})
 	if err != nil {
 		return false, err
 	}
 
-	v := <-outch
+	if t.key != "" {
+		// the persistent timertask maybe forgot after fired, so we register it again
+		if err := tw.remember(t, next); err != nil {
+			return false, err
+		}
+	}
 	return v.(bool), nil
 }
 
-func (tw *timingWheel) addFunc(d time.Duration, f Handler, eType timerTaskType) (TimerTask, error) {
+func (tw *timingWheel) AfterPersistFunc(key string, d time.Duration, payload []byte) (TimerTask, error) {
+	if tw.s == nil {
+		return nil, ErrNoStore
+	}
+	if key == "" {
+		return nil, ErrInvalidKey
+	}
+
+	r := Record{
+		Key:      key,
+		Deadline: tw.c.Now().Add(d),
+		Payload:  payload,
+	}
+
+	tw.pmu.Lock()
+	if _, ok := tw.pm[key]; ok {
+		tw.pmu.Unlock()
+		return nil, ErrDuplicateKey
+	}
+	if err := tw.s.Save(r); err != nil {
+		tw.pmu.Unlock()
+		return nil, err
+	}
+	t := tw.newPersistentTask(r)
+	tw.pm[key] = t
+	tw.pmu.Unlock()
+
+	if err := tw.enqueue(event{Type: eventAddNew, t: t}); err != nil {
+		tw.forget(t)
+		return nil, err
+	}
+	return t, nil
+}
+
+func (tw *timingWheel) Persistent(key string) (TimerTask, bool) {
+	tw.pmu.Lock()
+	defer tw.pmu.Unlock()
+
+	t, ok := tw.pm[key]
+	if !ok {
+		return nil, false
+	}
+	return t, true
+}
+
+// recover the persistent timertask from the Store, it's called before the event loop started.
+func (tw *timingWheel) recover(policy RecoverPolicy) error {
+	records, err := tw.s.Load()
+	if err != nil {
+		return err
+	}
+
+	now := tw.c.Now()
+	for _, r := range records {
+		if !tw.own(r.Key) {
+			// the timertask belongs to other wheel in the ShardedTimingWheel
+			continue
+		}
+
+		if r.Deadline.Before(now) && policy == RecoverDrop {
+			if err := tw.s.Delete(r.Key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// the overdue timertask will been fired immediately when the event loop started
+		t := tw.newPersistentTask(r)
+		tw.pm[r.Key] = t
+		tw.q.Push(event{Type: eventAddNew, t: t})
+	}
+	return nil
+}
+
+// newPersistentTask creates the timertask with the Record, the timertask will been forgot
+// after the PersistentHandler called.
+func (tw *timingWheel) newPersistentTask(r Record) *timerTask {
+	id := tw.nextID()
 	t := &timerTask{
-		d:          d,
-		expiration: timeToMs(time.Now().Add(d)),
-		t:          eType,
-		f:          f,
-		id:         atomic.AddUint64(&tw.wid, 1),
-		w:          tw,
+		t:       taskAfter,
+		id:      id,
+		w:       tw,
+		u:       tw.u,
+		key:     r.Key,
+		payload: r.Payload,
+	}
+	t.f = tw.wrap(id, func(ctx context.Context, now time.Time) error {
+		defer tw.forget(t)
+		return tw.sh(ctx, t.key, t.payload, now)
+	})
+	t.setNext(r.Deadline)
+	return t
+}
+
+// remember register the persistent timertask with the next fire time
+func (tw *timingWheel) remember(t *timerTask, next time.Time) error {
+	tw.pmu.Lock()
+	defer tw.pmu.Unlock()
+
+	if o, ok := tw.pm[t.key]; ok && o != t {
+		return ErrDuplicateKey
+	}
+	if err := tw.s.Save(Record{Key: t.key, Deadline: next, Payload: t.payload}); err != nil {
+		return err
+	}
+	tw.pm[t.key] = t
+	return nil
+}
+
+// forget the persistent timertask, and delete it from the Store
+func (tw *timingWheel) forget(t *timerTask) {
+	err := func() error {
+		tw.pmu.Lock()
+		defer tw.pmu.Unlock()
+
+		if tw.pm[t.key] != t {
+			// the key has been registered by other timertask
+			return nil
+		}
+		delete(tw.pm, t.key)
+		return tw.s.Delete(t.key)
+	}()
+	if err != nil && tw.eh != nil {
+		tw.eh(t.id, err)
+	}
+}
+
+func (tw *timingWheel) Stats() Stats {
+	tw.mu.RLock()
+	if !tw.started {
+		// the event loop isn't running, so the wheel will not been changed before we unlock
+		defer tw.mu.RUnlock()
+		return tw.stats()
 	}
+	tw.mu.RUnlock()
 
-	outch, err := tw.enquenTask(t, eventAddNew)
+	v, err := tw.request(event{
+		Type: eventStats,
+	})
 	if err != nil {
+		// the wheel is stopped, wait for the event loop exited
+		<-tw.done
+		return tw.stats()
+	}
+	return v.(Stats)
+}
+
+// stats returns the snapshot of timingwheel, it must been called in the event loop
+// or when the event loop isn't running.
+func (tw *timingWheel) stats() Stats {
+	layers := tw.w.stats(tw.u)
+	pending := 0
+	for _, l := range layers {
+		pending += l.Timers
+	}
+
+	return Stats{
+		Layers:  layers,
+		Pending: pending,
+		Buckets: tw.dq.Size(),
+		Fired:   atomic.LoadUint64(&tw.cnt.fired),
+		Stopped: atomic.LoadUint64(&tw.cnt.stopped),
+		Panics:  atomic.LoadUint64(&tw.cnt.panics),
+		Lag:     time.Duration(atomic.LoadInt64(&tw.cnt.lag)),
+	}
+}
+
+func (tw *timingWheel) addFunc(d time.Duration, f ContextHandler, eType timerTaskType, o tickOption, g *group) (TimerTask, error) {
+	id := tw.nextID()
+	t := &timerTask{
+		d:  d,
+		t:  eType,
+		id: id,
+		w:  tw,
+		u:  tw.u,
+		o:  o,
+		g:  g,
+	}
+	if g != nil && eType == taskAfter {
+		// the after timertask leave the group after fired
+		h := f
+		f = func(ctx context.Context, now time.Time) error {
+			g.leave(t)
+			return h(ctx, now)
+		}
+	}
+	t.f = tw.wrap(id, f)
+	t.setNext(tw.c.Now().Add(d))
+	return tw.add(t)
+}
+
+// add the timertask into the wheel by the event loop, we return it immediately without waiting.
+func (tw *timingWheel) add(t *timerTask) (TimerTask, error) {
+	t.pending = true
+	if t.g != nil {
+		if err := t.g.join(t); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.enqueue(event{Type: eventAddNew, t: t}); err != nil {
+		if t.g != nil {
+			t.g.leave(t)
+		}
 		return nil, err
 	}
+	return t, nil
+}
+
+// wrap the ContextHandler of timertask into Handler, the panic will been recovered and the
+// error will been passed to the ErrorHandler.
+func (tw *timingWheel) wrap(id uint64, f ContextHandler) Handler {
+	return func(t time.Time) {
+		defer func() {
+			if r := recover(); r != nil {
+				atomic.AddUint64(&tw.cnt.panics, 1)
+				if tw.ph != nil {
+					tw.ph(id, r)
+				}
+			}
+		}()
+
+		if err := f(tw.ctx, t); err != nil && tw.eh != nil {
+			tw.eh(id, err)
+		}
+	}
+}
+
+// contextHandler is an adapter to use Handler as ContextHandler
+func contextHandler(f Handler) ContextHandler {
+	return func(_ context.Context, t time.Time) error {
+		f(t)
+		return nil
+	}
+}
 
-	v := <-outch
-	return v.(*timerTask), nil
+// nextID returns the unique id of timertask, it's unique in the ShardedTimingWheel too
+func (tw *timingWheel) nextID() uint64 {
+	return tw.sid | atomic.AddUint64(&tw.wid, 1)
 }
 
-func (tw *timingWheel) enquenTask(t *timerTask, eType eventType) (<-chan interface{}, error) {
-	outch, err := tw.wt.Register(t.id)
+// request enqueue the event and wait for the response from the event loop
+func (tw *timingWheel) request(e event) (interface{}, error) {
+	e.rid = atomic.AddUint64(&tw.wid, 1)
+	outch, err := tw.wt.Register(e.rid)
 	if err != nil {
 		return nil, err
 	}
 
-	tw.wch <- event{
-		Type: eType,
-		t:    t,
+	if tw.handleInline(e) {
+		return <-outch, nil
+	}
+	if err := tw.enqueue(e); err != nil {
+		_ = tw.wt.Trigger(e.rid, nil)
+		return nil, err
+	}
+	return <-outch, nil
+}
+
+// handleInline handle the event in the caller's goroutine if the event loop isn't started,
+// it returns false if the event should been handled by the event loop.
+func (tw *timingWheel) handleInline(e event) bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.started || tw.stopped {
+		return false
+	}
+	tw.handlePending(e)
+	return true
+}
+
+func (tw *timingWheel) enqueue(e event) error {
+	tw.mu.RLock()
+	defer tw.mu.RUnlock()
+
+	if tw.stopped {
+		return ErrWheelStopped
 	}
 
-	return outch, nil
+	tw.q.Push(e)
+	return nil
 }
@@ -25,6 +25,7 @@ package timingwheel
 import (
 	"context"
 	"math/rand"
+	"runtime"
 	"testing"
 	"time"
 
@@ -38,7 +39,7 @@ func Benchmark_TimingWheel_AfterFunc(b *testing.B) {
 	if err != nil {
 		b.FailNow()
 	}
-	tw.Start()
+	tw.Start(context.Background())
 
 	wg.Wrap(func() {
 		<-ctx.Done()
@@ -64,7 +65,7 @@ func Benchmark_TimingWheel_AfterFunc_Parallel(b *testing.B) {
 	if err != nil {
 		b.FailNow()
 	}
-	tw.Start()
+	tw.Start(context.Background())
 
 	wg.Wrap(func() {
 		<-ctx.Done()
@@ -93,7 +94,7 @@ func Benchmark_TimingWheel_TickFunc(b *testing.B) {
 	if err != nil {
 		b.FailNow()
 	}
-	tw.Start()
+	tw.Start(context.Background())
 
 	wg.Wrap(func() {
 		<-ctx.Done()
@@ -120,7 +121,7 @@ func Benchmark_TimingWheel_TickFunc_Parallel(b *testing.B) {
 	if err != nil {
 		b.FailNow()
 	}
-	tw.Start()
+	tw.Start(context.Background())
 
 	wg.Wrap(func() {
 		<-ctx.Done()
@@ -150,7 +151,7 @@ func Benchmark_TimingWheel(b *testing.B) {
 	if err != nil {
 		b.FailNow()
 	}
-	tw.Start()
+	tw.Start(context.Background())
 
 	wg.Wrap(func() {
 		<-ctx.Done()
@@ -182,7 +183,7 @@ func Benchmark_TimingWheel_Parallel(b *testing.B) {
 	if err != nil {
 		b.FailNow()
 	}
-	tw.Start()
+	tw.Start(context.Background())
 
 	wg.Wrap(func() {
 		<-ctx.Done()
@@ -209,3 +210,33 @@ func Benchmark_TimingWheel_Parallel(b *testing.B) {
 	cancel()
 	wg.Wait()
 }
+
+func Benchmark_ShardedTimingWheel_TickFunc_Parallel(b *testing.B) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg conc.WaitGroupWrapper
+	tw, err := NewShardedTimingWheel(runtime.GOMAXPROCS(0), WithTickDuration(time.Millisecond), WithSize(20))
+	if err != nil {
+		b.FailNow()
+	}
+	tw.Start(context.Background())
+
+	wg.Wrap(func() {
+		<-ctx.Done()
+		tw.Stop()
+	})
+
+	rand.Seed(time.Now().UnixNano())
+
+	b.RunParallel(func(p *testing.PB) {
+		for p.Next() {
+			t, _ := tw.TickFunc(
+				time.Duration(rand.Intn(300)+1)*time.Millisecond,
+				func(time.Time) {},
+			)
+			t.Stop()
+		}
+	})
+
+	cancel()
+	wg.Wait()
+}
@@ -24,6 +24,10 @@ package timingwheel
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -31,7 +35,9 @@ import (
 
 	"github.com/stretchr/testify/suite"
 
+	"github.com/lsytj0413/ena/clock"
 	"github.com/lsytj0413/ena/conc"
+	"github.com/lsytj0413/ena/delayqueue"
 )
 
 type timingWheelTestSuite struct {
@@ -41,14 +47,9 @@ type timingWheelTestSuite struct {
 }
 
 func (s *timingWheelTestSuite) SetupTest() {
-	tw, err := NewTimingWheel(WithTickDuration(time.Millisecond), WithSize(20))
+	tw, err := NewTimingWheel(WithTickDuration(time.Millisecond), WithSize(20), WithExecutor(ExecutorFunc(blockExecutor)))
 	s.NoError(err)
 	s.tw = tw.(*timingWheel)
-	defaultExecutor = blockExecutor
-}
-
-func (s *timingWheelTestSuite) TearDownTest() {
-	defaultExecutor = taskExecutor
 }
 
 func (s *timingWheelTestSuite) TestNewTimingWheelInvalidTick() {
@@ -68,6 +69,54 @@ func (s *timingWheelTestSuite) TestNewTimingWheelInvalidTick() {
 	}
 }
 
+func (s *timingWheelTestSuite) TestNewTimingWheelInvalidTimeUnit() {
+	tw, err := NewTimingWheel(WithTimeUnit(0))
+	s.Equal(ErrInvalidTimeUnit, err)
+	s.Nil(tw)
+
+	tw, err = NewTimingWheel(WithTickDuration(999*time.Nanosecond), WithTimeUnit(time.Microsecond))
+	s.Equal(ErrInvalidTickValue, err)
+	s.Nil(tw)
+}
+
+func (s *timingWheelTestSuite) TestMicrosecondTick() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(100*time.Microsecond),
+		WithTimeUnit(time.Microsecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+
+	start := c.Now()
+	ch := make(chan time.Time, 1)
+	t, err := tw.AfterFunc(time.Millisecond+50*time.Microsecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+	s.Equal(start.Add(time.Millisecond+50*time.Microsecond), t.Deadline().UTC())
+	_, err = tw.TickFunc(50*time.Microsecond, func(time.Time) {})
+	s.Equal(ErrInvalidTickFuncDurationValue, err)
+
+	tw.Start(context.Background())
+	defer tw.Stop()
+	s.Equal(100*time.Microsecond, tw.Stats().Layers[0].Tick)
+
+	for i := 0; i < 10; i++ {
+		select {
+		case <-ch:
+			s.FailNow("expect AfterFunc not fired")
+		default:
+		}
+
+		c.BlockUntil(1)
+		c.Advance(100 * time.Microsecond)
+	}
+	s.Equal(start.Add(time.Millisecond), <-ch)
+}
+
 func (s *timingWheelTestSuite) TestNewTimingWheelInvalidWheelSize() {
 	values := []int64{
 		-100,
@@ -83,6 +132,12 @@ func (s *timingWheelTestSuite) TestNewTimingWheelInvalidWheelSize() {
 	}
 }
 
+func (s *timingWheelTestSuite) TestNewTimingWheelInvalidExecutor() {
+	tw, err := NewTimingWheel(WithTickDuration(time.Millisecond), WithSize(20), WithExecutor(nil))
+	s.Equal(ErrInvalidExecutor, err)
+	s.Nil(tw)
+}
+
 func (s *timingWheelTestSuite) TestNewTimingWheelOk() {
 	values := []time.Duration{
 		time.Millisecond,
@@ -134,7 +189,7 @@ func (s *timingWheelTestSuite) TestAfterFunc() {
 	}
 
 	var wg conc.WaitGroupWrapper
-	now := timeToMs(time.Now())
+	now := timeToUnit(time.Now(), time.Millisecond)
 	ctx, cancel := context.WithCancel(context.Background())
 	ch := make(chan struct{})
 
@@ -158,18 +213,18 @@ func (s *timingWheelTestSuite) TestAfterFunc() {
 					defer func() {
 						ch <- struct{}{}
 					}()
-					n := timeToMs(ct)
+					n := timeToUnit(ct, time.Millisecond)
 					expect := now + int64(tc.d/time.Millisecond)
 					s.T().Logf("receive: %s", tc.description)
 					if n < expect || n > expect+10 {
-						s.T().Fatalf("receive %s: expect[%v], got[%v]", tc.description, expect, n)
+						s.T().Errorf("receive %s: expect[%v], got[%v]", tc.description, expect, n)
 					}
 				}
 			}(tc))
 		}
 	})
 
-	s.tw.Start()
+	s.tw.Start(context.Background())
 	wg.Wrap(func() {
 		defer s.tw.Stop()
 		<-ctx.Done()
@@ -218,7 +273,7 @@ func (s *timingWheelTestSuite) TestTickFunc() {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
-	s.tw.Start()
+	s.tw.Start(context.Background())
 	wg.Wrap(func() {
 		for _, tc := range testCases {
 			now := time.Now()
@@ -237,7 +292,7 @@ func (s *timingWheelTestSuite) TestTickFunc() {
 
 					atomic.CompareAndSwapPointer(&tc.last, lastptr, unsafe.Pointer(&ct))
 					if expect.After(now.Add(2*time.Millisecond)) || now.After(expect.Add(10*time.Millisecond)) {
-						s.T().Fatalf("receive %s: expect[%v], got[%v], last[%v]", tc.description, expect, now, last)
+						s.T().Errorf("receive %s: expect[%v], got[%v], last[%v]", tc.description, expect, now, last)
 					}
 				}
 			}(tc))
@@ -262,6 +317,795 @@ func (s *timingWheelTestSuite) TestTickFunc() {
 	}
 }
 
+func (s *timingWheelTestSuite) TestFakeClock() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	start := c.Now()
+	ch := make(chan time.Time, 1)
+	_, err = tw.AfterFunc(100*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+
+	for i := 0; i < 100; i++ {
+		select {
+		case <-ch:
+			s.FailNow("expect AfterFunc not fired")
+		default:
+		}
+
+		// wait the delayqueue sleeping for the bucket
+		c.BlockUntil(1)
+		c.Advance(time.Millisecond)
+	}
+
+	n := <-ch
+	s.Equal(start.Add(100*time.Millisecond), n)
+}
+
+func (s *timingWheelTestSuite) TestDurableDelayQueue() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	dq, err := delayqueue.NewDurable(filepath.Join(s.T().TempDir(), "queue.log"), delayqueue.WithClock(c))
+	s.NoError(err)
+	defer dq.Close()
+
+	tw, err := NewTimingWheel(
+		WithTickDuration(time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+		WithDelayQueue(dq),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	start := c.Now()
+	ch := make(chan time.Time, 1)
+	_, err = tw.AfterFunc(30*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+	for i := 0; i < 30; i++ {
+		c.BlockUntil(1)
+		c.Advance(time.Millisecond)
+	}
+	s.Equal(start.Add(30*time.Millisecond), <-ch)
+	s.Equal(0, dq.Size())
+}
+
+func (s *timingWheelTestSuite) TestBeforeStart() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+	defer tw.Stop()
+
+	ch := make(chan int, 4)
+	newTask := func(v int) TimerTask {
+		tk, err := tw.AfterFunc(10*time.Millisecond, func(time.Time) {
+			ch <- v
+		})
+		s.NoError(err)
+		return tk
+	}
+
+	// the timertask is stopped or reset without the event loop
+	stopped := newTask(1)
+	s.True(stopped.Stop())
+	reset := newTask(2)
+	s.True(reset.Stop())
+	s.False(reset.Reset(5 * time.Millisecond))
+	s.True(newTask(3).Reset(20 * time.Millisecond))
+
+	g := tw.NewGroup(context.Background())
+	_, err = g.AfterFunc(10*time.Millisecond, func(time.Time) {
+		ch <- 4
+	})
+	s.NoError(err)
+	n, err := g.StopAll()
+	s.NoError(err)
+	s.Equal(1, n)
+
+	ctx, cancel := tw.WithTimeout(context.Background(), 10*time.Millisecond)
+	cancel()
+	s.Equal(context.Canceled, ctx.Err())
+	s.Equal(uint64(4), tw.Stats().Stopped)
+
+	tw.Start(context.Background())
+	for i := 0; i < 20; i++ {
+		c.BlockUntil(1)
+		c.Advance(time.Millisecond)
+	}
+	s.Equal(2, <-ch)
+	s.Equal(3, <-ch)
+	s.Equal(context.Canceled, ctx.Err())
+	select {
+	case v := <-ch:
+		s.FailNow("expect the stopped timertask not fired", "%v", v)
+	default:
+	}
+}
+
+func (s *timingWheelTestSuite) TestScheduleFunc() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(100*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	_, err = tw.ScheduleFunc(Every(0), func(time.Time) {})
+	s.Equal(ErrInvalidSchedule, err)
+	_, err = tw.ScheduleFunc(Every(10*time.Millisecond), func(time.Time) {})
+	s.Equal(ErrInvalidTickFuncDurationValue, err)
+
+	sc, err := ParseCron("TZ=UTC * * * * * *")
+	s.NoError(err)
+
+	start := c.Now()
+	ch := make(chan time.Time, 10)
+	t, err := tw.ScheduleFunc(sc, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+
+	for i := 1; i <= 3; i++ {
+		for j := 0; j < 10; j++ {
+			c.BlockUntil(1)
+			c.Advance(100 * time.Millisecond)
+		}
+
+		n := <-ch
+		s.Equal(start.Add(time.Duration(i)*time.Second), n)
+	}
+
+	stopped, err := t.Stop()
+	s.NoError(err)
+	s.True(stopped)
+}
+
+func (s *timingWheelTestSuite) TestScheduleFuncWithinTick() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(3*time.Second),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	// the schedule fires every second, but the wheel only fires once every tick
+	sc, err := ParseCron("TZ=UTC * * * * * *")
+	s.NoError(err)
+
+	start := c.Now()
+	ch := make(chan time.Time, 10)
+	t, err := tw.ScheduleFunc(sc, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+	s.Equal(start, <-ch)
+	s.WithinDuration(start.Add(3*time.Second), t.Deadline(), 0)
+
+	c.BlockUntil(1)
+	c.Advance(3 * time.Second)
+	s.Equal(start.Add(3*time.Second), <-ch)
+	s.Eventually(func() bool {
+		return t.Deadline().Equal(start.Add(6 * time.Second))
+	}, time.Second, time.Millisecond)
+	s.Equal(0, len(ch))
+}
+
+func (s *timingWheelTestSuite) TestReset() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	advance := func(d time.Duration) {
+		for ; d > 0; d -= 10 * time.Millisecond {
+			c.BlockUntil(1)
+			c.Advance(10 * time.Millisecond)
+		}
+	}
+	assertNotFired := func(ch chan time.Time) {
+		select {
+		case <-ch:
+			s.FailNow("expect not fired")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+
+	start := c.Now()
+	ch := make(chan time.Time, 10)
+	t, err := tw.AfterFunc(100*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+
+	// reset the active timertask
+	advance(50 * time.Millisecond)
+	active, err := t.Reset(100 * time.Millisecond)
+	s.NoError(err)
+	s.True(active)
+
+	advance(60 * time.Millisecond)
+	assertNotFired(ch)
+	advance(40 * time.Millisecond)
+	s.Equal(start.Add(150*time.Millisecond), <-ch)
+
+	// reset the expired timertask
+	active, err = t.Reset(50 * time.Millisecond)
+	s.NoError(err)
+	s.False(active)
+	advance(50 * time.Millisecond)
+	s.Equal(start.Add(200*time.Millisecond), <-ch)
+
+	// reset the stopped timertask
+	_, err = tw.AfterFunc(time.Hour, func(time.Time) {})
+	s.NoError(err)
+	stopped, err := t.Stop()
+	s.NoError(err)
+	s.False(stopped)
+	active, err = t.Reset(time.Minute)
+	s.NoError(err)
+	s.False(active)
+	stopped, err = t.Stop()
+	s.NoError(err)
+	s.True(stopped)
+	active, err = t.Reset(20 * time.Millisecond)
+	s.NoError(err)
+	s.False(active)
+	advance(20 * time.Millisecond)
+	s.Equal(start.Add(220*time.Millisecond), <-ch)
+
+	// reset the tick timertask with invalid duration
+	tick, err := tw.TickFunc(20*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+	_, err = tick.Reset(time.Millisecond)
+	s.Equal(ErrInvalidTickFuncDurationValue, err)
+
+	// reset the tick timertask with new interval
+	active, err = tick.Reset(30 * time.Millisecond)
+	s.NoError(err)
+	s.True(active)
+	advance(30 * time.Millisecond)
+	s.Equal(start.Add(250*time.Millisecond), <-ch)
+	advance(30 * time.Millisecond)
+	s.Equal(start.Add(280*time.Millisecond), <-ch)
+}
+
+func (s *timingWheelTestSuite) TestStats() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+
+	start := c.Now()
+	ch := make(chan time.Time, 1)
+	t1, err := tw.AfterFunc(50*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+	t2, err := tw.AfterFunc(500*time.Millisecond, func(time.Time) {})
+	s.NoError(err)
+	s.NotEqual(t1.ID(), t2.ID())
+	s.WithinDuration(start.Add(50*time.Millisecond), t1.Deadline(), 0)
+	s.WithinDuration(start.Add(500*time.Millisecond), t2.Deadline(), 0)
+
+	// the timertask haven't been add into the wheel before start
+	s.Equal(Stats{
+		Layers: []LayerStats{
+			{Tick: 10 * time.Millisecond, Interval: 200 * time.Millisecond, WheelSize: 20},
+		},
+	}, tw.Stats())
+
+	tw.Start(context.Background())
+	defer tw.Stop()
+	s.Equal(Stats{
+		Layers: []LayerStats{
+			{Tick: 10 * time.Millisecond, Interval: 200 * time.Millisecond, WheelSize: 20, Occupied: 1, Timers: 1},
+			{Tick: 200 * time.Millisecond, Interval: 4000 * time.Millisecond, WheelSize: 20, Occupied: 1, Timers: 1},
+		},
+		Pending: 2,
+		Buckets: 2,
+	}, tw.Stats())
+
+	stopped, err := t2.Stop()
+	s.NoError(err)
+	s.True(stopped)
+
+	for i := 0; i < 5; i++ {
+		c.BlockUntil(1)
+		c.Advance(10 * time.Millisecond)
+	}
+	s.Equal(start.Add(50*time.Millisecond), <-ch)
+
+	st := tw.Stats()
+	s.Equal(0, st.Pending)
+	s.Equal(uint64(1), st.Fired)
+	s.Equal(uint64(1), st.Stopped)
+	s.Equal(uint64(0), st.Panics)
+	s.Equal(time.Duration(0), st.Lag)
+
+	tw.Stop()
+	st = tw.Stats()
+	s.Equal(uint64(1), st.Fired)
+}
+
+func (s *timingWheelTestSuite) TestOverflowInterval() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(100*time.Microsecond),
+		WithTimeUnit(time.Nanosecond),
+		WithSize(64),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	// the 100µs*64^8 is overflow, the top layer's interval will been saturated
+	_, err = tw.AfterFunc(20*365*24*time.Hour, func(time.Time) {})
+	s.NoError(err)
+	// the expiration is after maxTime, it will been saturated
+	_, err = tw.AfterFunc(290*365*24*time.Hour, func(time.Time) {})
+	s.NoError(err)
+
+	st := tw.Stats()
+	s.Equal(2, st.Pending)
+	s.Equal(8, len(st.Layers))
+	s.Equal(time.Duration(math.MaxInt64), st.Layers[7].Interval)
+	s.Equal(2, st.Layers[7].Timers)
+}
+
+func (s *timingWheelTestSuite) TestPanicHandler() {
+	type panicValue struct {
+		id uint64
+		r  interface{}
+	}
+	pch := make(chan panicValue, 1)
+
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+		WithPanicHandler(func(id uint64, r interface{}) {
+			pch <- panicValue{id: id, r: r}
+		}),
+	)
+	s.NoError(err)
+
+	t, err := tw.AfterFunc(10*time.Millisecond, func(time.Time) {
+		panic("MockPanic")
+	})
+	s.NoError(err)
+	ch := make(chan time.Time, 1)
+	_, err = tw.AfterFunc(20*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+
+	// start after the timertask added, so the delayqueue will not been wakeup
+	// when the fake clock advanced
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	c.BlockUntil(1)
+	c.Advance(10 * time.Millisecond)
+	s.Equal(panicValue{id: t.ID(), r: "MockPanic"}, <-pch)
+
+	// the event loop is still running after the Handler panics
+	c.BlockUntil(1)
+	c.Advance(10 * time.Millisecond)
+	<-ch
+	s.Equal(uint64(1), tw.Stats().Panics)
+}
+
+func (s *timingWheelTestSuite) TestDefaultPanicHandler() {
+	ch := make(chan time.Time, 1)
+	_, err := s.tw.AfterFunc(10*time.Millisecond, func(time.Time) {
+		panic("MockPanic")
+	})
+	s.NoError(err)
+	_, err = s.tw.AfterFunc(20*time.Millisecond, func(t time.Time) {
+		ch <- t
+	})
+	s.NoError(err)
+
+	s.tw.Start(context.Background())
+	defer s.tw.Stop()
+	<-ch
+	s.Equal(uint64(1), s.tw.Stats().Panics)
+}
+
+func (s *timingWheelTestSuite) TestContextFunc() {
+	type errValue struct {
+		id  uint64
+		err error
+	}
+	ech := make(chan errValue, 10)
+
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(10*time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+		WithErrorHandler(func(id uint64, err error) {
+			ech <- errValue{id: id, err: err}
+		}),
+	)
+	s.NoError(err)
+
+	merr := fmt.Errorf("MockError")
+	f := func(ctx context.Context, t time.Time) error {
+		s.NoError(ctx.Err())
+		return merr
+	}
+	t1, err := tw.AfterContextFunc(10*time.Millisecond, f)
+	s.NoError(err)
+	t2, err := tw.TickContextFunc(20*time.Millisecond, f)
+	s.NoError(err)
+	t3, err := tw.ScheduleContextFunc(Every(30*time.Millisecond), f)
+	s.NoError(err)
+	_, err = tw.AfterContextFunc(40*time.Millisecond, func(context.Context, time.Time) error {
+		return nil
+	})
+	s.NoError(err)
+
+	tw.Start(context.Background())
+	defer tw.Stop()
+	for _, id := range []uint64{t1.ID(), t2.ID(), t3.ID(), t2.ID()} {
+		c.BlockUntil(1)
+		c.Advance(10 * time.Millisecond)
+
+		// wait the Handler complete before next advance
+		s.Equal(errValue{id: id, err: merr}, <-ech)
+	}
+}
+
+func (s *timingWheelTestSuite) TestTickFuncInvalidOption() {
+	_, err := s.tw.TickFunc(time.Second, func(time.Time) {}, WithTickPolicy(TickPolicy(10)))
+	s.Equal(ErrInvalidTickPolicy, err)
+
+	_, err = s.tw.TickFunc(time.Second, func(time.Time) {}, WithMissedTickPolicy(MissedTickPolicy(10)))
+	s.Equal(ErrInvalidTickPolicy, err)
+
+	_, err = s.tw.TickFunc(time.Second, func(time.Time) {}, WithJitter(-time.Second))
+	s.Equal(ErrInvalidJitter, err)
+}
+
+func (s *timingWheelTestSuite) TestTickFuncPolicy() {
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	tw, err := NewTimingWheel(
+		WithTickDuration(time.Millisecond),
+		WithSize(20),
+		WithExecutor(ExecutorFunc(blockExecutor)),
+		WithClock(c),
+	)
+	s.NoError(err)
+	tw.Start(context.Background())
+	defer tw.Stop()
+
+	type fired struct {
+		name string
+		t    time.Time
+	}
+	ch := make(chan fired, 10)
+	tick := func(name string, opts ...TickOption) {
+		_, err := tw.TickFunc(10*time.Millisecond, func(t time.Time) {
+			ch <- fired{name: name, t: t}
+		}, opts...)
+		s.NoError(err)
+	}
+	tick("delay")
+	tick("skip", WithTickPolicy(TickFixedRate))
+	tick("catchup", WithTickPolicy(TickFixedRate), WithMissedTickPolicy(MissedTickCatchUp))
+
+	received := func(n int) map[string]int {
+		ret := map[string]int{}
+		for i := 0; i < n; i++ {
+			v := <-ch
+			ret[v.name]++
+		}
+		return ret
+	}
+
+	// the event loop is paused for a long time
+	start := c.Now()
+	c.BlockUntil(1)
+	c.Advance(35 * time.Millisecond)
+	s.Equal(map[string]int{"delay": 1, "skip": 1, "catchup": 3}, received(5))
+
+	// the fixed rate timertask is anchored to the start time
+	for c.Now().Before(start.Add(40 * time.Millisecond)) {
+		c.BlockUntil(1)
+		c.Advance(time.Millisecond)
+	}
+	s.Equal(map[string]int{"skip": 1, "catchup": 1}, received(2))
+
+	for c.Now().Before(start.Add(45 * time.Millisecond)) {
+		c.BlockUntil(1)
+		c.Advance(time.Millisecond)
+	}
+	s.Equal(map[string]int{"delay": 1}, received(1))
+}
+
+func (s *timingWheelTestSuite) TestPersistentInvalid() {
+	st, err := NewFileStore(filepath.Join(s.T().TempDir(), "timers.log"))
+	s.NoError(err)
+	defer st.Close()
+
+	_, err = NewTimingWheel(WithStore(st, nil))
+	s.Equal(ErrInvalidPersistentHandler, err)
+
+	_, err = NewTimingWheel(WithRecoverPolicy(RecoverPolicy(10)))
+	s.Equal(ErrInvalidRecoverPolicy, err)
+
+	_, err = s.tw.AfterPersistFunc("a", time.Second, nil)
+	s.Equal(ErrNoStore, err)
+
+	tw, err := NewTimingWheel(WithStore(st, func(context.Context, string, []byte, time.Time) error {
+		return nil
+	}))
+	s.NoError(err)
+	_, err = tw.AfterPersistFunc("", time.Second, nil)
+	s.Equal(ErrInvalidKey, err)
+}
+
+func (s *timingWheelTestSuite) TestPersistent() {
+	type fired struct {
+		key     string
+		payload string
+		t       time.Time
+	}
+	ch := make(chan fired, 10)
+	h := func(_ context.Context, key string, payload []byte, t time.Time) error {
+		ch <- fired{key: key, payload: string(payload), t: t}
+		return nil
+	}
+
+	path := filepath.Join(s.T().TempDir(), "timers.log")
+	st, err := NewFileStore(path)
+	s.NoError(err)
+	c := clock.NewFake(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	start := c.Now()
+	newTimingWheel := func(opts ...Option) TimingWheel {
+		tw, err := NewTimingWheel(append([]Option{
+			WithTickDuration(10 * time.Millisecond),
+			WithSize(20),
+			WithExecutor(ExecutorFunc(blockExecutor)),
+			WithClock(c),
+			WithStore(st, h),
+		}, opts...)...)
+		s.NoError(err)
+		return tw
+	}
+	keys := func() []string {
+		records, err := st.Load()
+		s.NoError(err)
+		ret := []string{}
+		for _, r := range records {
+			ret = append(ret, r.Key)
+		}
+		sort.Strings(ret)
+		return ret
+	}
+
+	tw := newTimingWheel()
+	tw.Start(context.Background())
+	_, err = tw.AfterPersistFunc("a", 50*time.Millisecond, []byte("pa"))
+	s.NoError(err)
+	_, err = tw.AfterPersistFunc("b", 500*time.Millisecond, []byte("pb"))
+	s.NoError(err)
+	tc, err := tw.AfterPersistFunc("c", time.Hour, []byte("pc"))
+	s.NoError(err)
+	_, err = tw.AfterPersistFunc("c", time.Hour, nil)
+	s.Equal(ErrDuplicateKey, err)
+
+	v, ok := tw.Persistent("c")
+	s.True(ok)
+	s.Equal(tc, v)
+	stopped, err := tc.Stop()
+	s.NoError(err)
+	s.True(stopped)
+	_, ok = tw.Persistent("c")
+	s.False(ok)
+	s.Equal([]string{"a", "b"}, keys())
+
+	// the persistent timer is kept in the Store after stopped
+	tw.Stop()
+	s.Equal([]string{"a", "b"}, keys())
+
+	// restart after a while, the overdue timer will been fired
+	c.Advance(100 * time.Millisecond)
+	tw = newTimingWheel()
+	_, ok = tw.Persistent("a")
+	s.True(ok)
+	tw.Start(context.Background())
+	s.Equal(fired{key: "a", payload: "pa", t: start.Add(100 * time.Millisecond)}, <-ch)
+	s.Eventually(func() bool {
+		_, ok := tw.Persistent("a")
+		return !ok
+	}, time.Second, time.Millisecond)
+	s.Equal([]string{"b"}, keys())
+	tw.Stop()
+
+	// restart after the timer overdue with RecoverDrop
+	c.Advance(time.Second)
+	tw = newTimingWheel(WithRecoverPolicy(RecoverDrop))
+	_, ok = tw.Persistent("b")
+	s.False(ok)
+	s.Equal([]string{}, keys())
+	tw.Stop()
+	s.NoError(st.Close())
+
+	select {
+	case v := <-ch:
+		s.FailNow("unexpected fired", "%v", v)
+	default:
+	}
+}
+
+func (s *timingWheelTestSuite) TestNewTimingWheelInvalidClock() {
+	tw, err := NewTimingWheel(WithTickDuration(time.Millisecond), WithSize(20), WithClock(nil))
+	s.Equal(ErrInvalidClock, err)
+	s.Nil(tw)
+}
+
+func (s *timingWheelTestSuite) TestShutdownInvalidMode() {
+	err := s.tw.Shutdown(context.Background(), ShutdownMode(100))
+	s.Equal(ErrInvalidShutdownMode, err)
+}
+
+func (s *timingWheelTestSuite) TestShutdownDrop() {
+	s.tw.Start(context.Background())
+
+	var c int32
+	f := func(time.Time) {
+		atomic.AddInt32(&c, 1)
+	}
+	t1, err := s.tw.AfterFunc(time.Hour, f)
+	s.NoError(err)
+	t2, err := s.tw.TickFunc(time.Hour, f)
+	s.NoError(err)
+
+	s.NoError(s.tw.Shutdown(context.Background(), ShutdownDrop))
+	s.Equal(int32(0), atomic.LoadInt32(&c))
+	s.Equal(0, s.tw.w.size())
+
+	for _, t := range []TimerTask{t1, t2} {
+		stopped, err := t.Stop()
+		s.NoError(err)
+		s.True(stopped)
+	}
+
+	_, err = s.tw.AfterFunc(time.Hour, f)
+	s.Equal(ErrWheelStopped, err)
+	_, err = s.tw.TickFunc(time.Hour, f)
+	s.Equal(ErrWheelStopped, err)
+	s.Equal(ErrWheelStopped, s.tw.Shutdown(context.Background(), ShutdownDrop))
+	s.tw.Stop()
+}
+
+func (s *timingWheelTestSuite) TestShutdownFire() {
+	s.tw.Start(context.Background())
+
+	var c int32
+	f := func(time.Time) {
+		atomic.AddInt32(&c, 1)
+	}
+	_, err := s.tw.AfterFunc(time.Hour, f)
+	s.NoError(err)
+	_, err = s.tw.TickFunc(time.Hour, f)
+	s.NoError(err)
+
+	s.NoError(s.tw.Shutdown(context.Background(), ShutdownFire))
+	s.Equal(int32(2), atomic.LoadInt32(&c))
+	s.Equal(0, s.tw.w.size())
+}
+
+func (s *timingWheelTestSuite) TestShutdownWait() {
+	s.tw.Start(context.Background())
+
+	var c1, c2, c3 int32
+	_, err := s.tw.AfterFunc(10*time.Millisecond, func(time.Time) {
+		atomic.AddInt32(&c1, 1)
+	})
+	s.NoError(err)
+	_, err = s.tw.TickFunc(20*time.Millisecond, func(time.Time) {
+		atomic.AddInt32(&c2, 1)
+	})
+	s.NoError(err)
+	t3, err := s.tw.AfterFunc(time.Hour, func(time.Time) {
+		atomic.AddInt32(&c3, 1)
+	})
+	s.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s.NoError(s.tw.Shutdown(ctx, ShutdownWait))
+	s.NoError(ctx.Err())
+	s.Equal(int32(1), atomic.LoadInt32(&c1))
+	s.Equal(int32(1), atomic.LoadInt32(&c2))
+	s.Equal(int32(0), atomic.LoadInt32(&c3))
+
+	stopped, err := t3.Stop()
+	s.NoError(err)
+	s.True(stopped)
+}
+
+func (s *timingWheelTestSuite) TestShutdownWaitRunningHandler() {
+	tw, err := NewTimingWheel(WithTickDuration(time.Millisecond), WithSize(20))
+	s.NoError(err)
+	tw.Start(context.Background())
+
+	release := make(chan struct{})
+	_, err = tw.AfterFunc(time.Hour, func(time.Time) {
+		<-release
+	})
+	s.NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	s.Equal(context.DeadlineExceeded, tw.Shutdown(ctx, ShutdownFire))
+	close(release)
+}
+
+func (s *timingWheelTestSuite) TestStartContextDone() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.tw.Start(ctx)
+
+	t, err := s.tw.AfterFunc(time.Hour, func(time.Time) {})
+	s.NoError(err)
+
+	cancel()
+	<-s.tw.done
+
+	stopped, err := t.Stop()
+	s.NoError(err)
+	s.True(stopped)
+
+	_, err = s.tw.AfterFunc(time.Hour, func(time.Time) {})
+	s.Equal(ErrWheelStopped, err)
+}
+
 func (s *timingWheelTestSuite) TestTickFuncFailed() {
 	type tickDuration struct {
 		desp string
@@ -394,7 +1238,7 @@ func (s *timingWheelTestSuite) TestTickFuncOk() {
 	for _, tc := range testCases {
 		tw, err := NewTimingWheel(WithTickDuration(tc.tick), WithSize(20))
 		s.NoError(err)
-		tw.Start()
+		tw.Start(context.Background())
 		for _, tcd := range tc.ds {
 			s.Run(tc.desp+"-"+tcd.desp, func() {
 				tt, err := tw.TickFunc(tcd.d, func(time.Time) {})
@@ -22,28 +22,124 @@
 
 package timingwheel
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 // Handler for function execution
 type Handler func(time.Time)
 
+// ContextHandler for function execution with context, the ctx will been done when the
+// TimingWheel is stopped, the returned error will been passed to the ErrorHandler.
+type ContextHandler func(ctx context.Context, t time.Time) error
+
+// PersistentHandler for persistent timer execution, it's called with the key and payload of
+// the timer, the returned error will been passed to the ErrorHandler.
+type PersistentHandler func(ctx context.Context, key string, payload []byte, t time.Time) error
+
+// PanicHandler is called with the timertask's id and the recovered value when the Handler panics.
+type PanicHandler func(id uint64, r interface{})
+
+// ErrorHandler is called with the timertask's id and the error returned by the ContextHandler.
+type ErrorHandler func(id uint64, err error)
+
 // TimingWheel is an interface for implementation.
 type TimingWheel interface {
-	// Start starts the current timing wheel
-	Start()
+	// Start starts the current timing wheel, the timing wheel will been stopped
+	// as Stop when the ctx is done.
+	Start(ctx context.Context)
 
-	// Stop stops the current timing wheel. If there is any timer's task being running, the stop
-	// will not wait for complete.
-	// TODO(lsytj0413): should pass ctx from Start?
+	// Stop stops the current timing wheel, all the pending timer will been dropped.
+	// If there is any timer's task being running, the stop will not wait for complete.
 	Stop()
 
+	// Shutdown stops the current timing wheel, the pending timer is dealt with the mode,
+	// and then waits for the running timer's task to complete or the ctx is done.
+	// After shutdown, the AfterFunc/TickFunc will return ErrWheelStopped.
+	Shutdown(ctx context.Context, mode ShutdownMode) error
+
 	// AfterFunc will call the Handler in its own goroutine after the duration elapse.
 	// It return an Timer that can use to cancel the Handler.
 	AfterFunc(d time.Duration, f Handler) (TimerTask, error)
 
 	// TickFunc will call the Handler in its own goroutine after the duration elapse tick.
 	// It reutrn an Timer that can use to cancel the Handler.
-	TickFunc(d time.Duration, f Handler) (TimerTask, error)
+	// The TickOption can be used to change the policy of next fire time.
+	TickFunc(d time.Duration, f Handler, opts ...TickOption) (TimerTask, error)
+
+	// ScheduleFunc will call the Handler in its own goroutine at every time the Schedule returns.
+	// It return an Timer that can use to cancel the Handler.
+	// The Every schedule's duration must greater than or equal to the tick, and the next fire time
+	// of other schedule will been delayed to the next tick if it's within the current tick.
+	ScheduleFunc(s Schedule, f Handler) (TimerTask, error)
+
+	// AfterContextFunc is same as AfterFunc, but call the ContextHandler.
+	AfterContextFunc(d time.Duration, f ContextHandler) (TimerTask, error)
+
+	// TickContextFunc is same as TickFunc, but call the ContextHandler.
+	TickContextFunc(d time.Duration, f ContextHandler, opts ...TickOption) (TimerTask, error)
+
+	// ScheduleContextFunc is same as ScheduleFunc, but call the ContextHandler.
+	ScheduleContextFunc(s Schedule, f ContextHandler) (TimerTask, error)
+
+	// AfterPersistFunc will call the PersistentHandler in its own goroutine after the duration elapse.
+	// The timer is saved into the Store with the key and payload, and will been recovered by
+	// NewTimingWheel after restart, it's deleted from the Store after fired or stopped.
+	AfterPersistFunc(key string, d time.Duration, payload []byte) (TimerTask, error)
+
+	// Persistent returns the persistent timer with the key, include the recovered one.
+	Persistent(key string) (TimerTask, bool)
+
+	// NewTimer creates the Timer like time.NewTimer, the current time will been sent on
+	// its channel after the duration elapse.
+	NewTimer(d time.Duration) (*Timer, error)
+
+	// NewTicker creates the Ticker like time.NewTicker, the current time will been sent on
+	// its channel at every tick. The TickOption can be used to change the policy of next tick.
+	NewTicker(d time.Duration, opts ...TickOption) (*Ticker, error)
+
+	// WithTimeout returns WithDeadline(parent, Now().Add(d)).
+	WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc)
+
+	// WithDeadline returns the copy of parent context like context.WithDeadline, but the deadline is
+	// driven by the timertask of TimingWheel, and the timertask will been stopped when the CancelFunc
+	// is called. It fallback to context.WithDeadline if the TimingWheel is stopped.
+	WithDeadline(parent context.Context, deadline time.Time) (context.Context, context.CancelFunc)
+
+	// NewGroup creates the Group of timer, all the timer in the Group can been stopped by StopAll.
+	// The Group will been stopped when the ctx is done.
+	NewGroup(ctx context.Context) Group
+
+	// Stats returns the snapshot of the current timing wheel, such as the pending timer count
+	// of every layer, it can be used to export metrics.
+	Stats() Stats
+}
+
+// Group is the set of timer which can been stopped together, such as the timers of single session.
+type Group interface {
+	// AfterFunc is same as TimingWheel.AfterFunc, the timer is add into the Group.
+	AfterFunc(d time.Duration, f Handler) (TimerTask, error)
+
+	// TickFunc is same as TimingWheel.TickFunc, the timer is add into the Group.
+	TickFunc(d time.Duration, f Handler, opts ...TickOption) (TimerTask, error)
+
+	// ScheduleFunc is same as TimingWheel.ScheduleFunc, the timer is add into the Group.
+	ScheduleFunc(s Schedule, f Handler) (TimerTask, error)
+
+	// Len returns the count of timer in the Group, the timer will leave the Group after
+	// fired or stopped, except the repetitious one which is only leave after stopped.
+	Len() int
+
+	// StopAll stops all the timer in the Group in single pass of the event loop, it returns
+	// the count of timer which is stopped. The Group can't add timer after StopAll.
+	StopAll() (int, error)
+}
+
+// Schedule describes the fire time of repetitious timer, such as cron expression.
+type Schedule interface {
+	// Next returns the next fire time after t, or the zero time if there is no more.
+	Next(t time.Time) time.Time
 }
 
 // TimerTask is an interface for task implementation.
@@ -51,4 +147,17 @@ type TimerTask interface {
 	// Stop the timertask, the Handler will not be execute after this.
 	// NOTE: there is not promise the pre Hander call will been executed before stop.
 	Stop() (bool, error)
+
+	// Reset changes the timertask to expire after duration d, it returns true if the timertask
+	// had been active, false if the timertask had expired or been stopped. The timertask will
+	// be active after Reset even it had expired or been stopped, like time.Timer.Reset.
+	// For the TickFunc timertask, the d will be the new interval, and the TickFixedRate
+	// timertask will be anchored to the new fire time.
+	Reset(d time.Duration) (bool, error)
+
+	// ID returns the unique id of the timertask in the TimingWheel.
+	ID() uint64
+
+	// Deadline returns the time when the timertask will been fired next.
+	Deadline() time.Time
 }
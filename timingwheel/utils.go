@@ -22,11 +22,34 @@
 
 package timingwheel
 
-import "time"
+import (
+	"math"
+	"time"
+)
 
-// timeToMs returns the represents t in milliseconds
-func timeToMs(t time.Time) int64 {
-	return int64(time.Duration(t.UnixNano()) / time.Millisecond)
+// maxTime is the max time witch can been represented by UnixNano
+var maxTime = time.Unix(0, math.MaxInt64)
+
+// timeToUnit returns the represents t in unit, such as milliseconds,
+// it's saturated to maxTime when t is after maxTime
+func timeToUnit(t time.Time, unit time.Duration) int64 {
+	if t.After(maxTime) {
+		t = maxTime
+	}
+	return int64(time.Duration(t.UnixNano()) / unit)
+}
+
+// unitToDuration returns the duration of v in unit, it's saturated when overflow
+func unitToDuration(v int64, unit time.Duration) time.Duration {
+	if v > int64(math.MaxInt64/unit) {
+		return math.MaxInt64
+	}
+	return time.Duration(v) * unit
+}
+
+// unitToTime returns the local time corresponding to the given v in unit
+func unitToTime(v int64, unit time.Duration) time.Time {
+	return time.Unix(0, v*int64(unit))
 }
 
 // truncate returns the result of rounding x toward zero to a multiple of m.
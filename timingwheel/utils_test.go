@@ -24,6 +24,7 @@ package timingwheel
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -79,6 +80,15 @@ func (s *utilsTestSuite) TestTruncate() {
 	}
 }
 
+func (s *utilsTestSuite) TestTimeToUnit() {
+	t := time.Date(2019, 1, 1, 0, 0, 0, 123456789, time.UTC)
+	for _, unit := range []time.Duration{time.Nanosecond, time.Microsecond, time.Millisecond, time.Second} {
+		v := timeToUnit(t, unit)
+		s.Equal(t.UnixNano()/int64(unit), v)
+		s.True(t.Truncate(unit).Equal(unitToTime(v, unit)))
+	}
+}
+
 func TestUtilsTestSuite(t *testing.T) {
 	s := &utilsTestSuite{}
 	suite.Run(t, s)
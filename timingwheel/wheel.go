@@ -23,8 +23,10 @@
 package timingwheel
 
 import (
+	"math"
 	"time"
 
+	"github.com/lsytj0413/ena/clock"
 	"github.com/lsytj0413/ena/delayqueue"
 )
 
@@ -36,28 +38,35 @@ func newWheel(tickMs int64, wheelSize int64, startMs int64) *wheel {
 		buckets[i] = newBucket()
 	}
 
+	// the interval is saturated when overflow, so the layer can representation all the
+	// expiration after it and there is no more overflow wheel
+	interval := int64(math.MaxInt64)
+	if wheelSize <= 0 || tickMs <= math.MaxInt64/wheelSize {
+		interval = tickMs * wheelSize
+	}
+
 	return &wheel{
 		tick:        tickMs,
 		wheelSize:   wheelSize,
-		interval:    tickMs * wheelSize,
+		interval:    interval,
 		currentTime: truncate(startMs, tickMs),
 		buckets:     buckets,
 	}
 }
 
 type wheel struct {
-	// tick is the interval of every bucket representation in milliseconds,
-	// it the min expire unit in the timmingWheel, so it always be time.Milliseconds in the first
-	// layer timingWheel.
+	// tick is the interval of every bucket representation in the time unit,
+	// it the min expire unit in the timmingWheel.
 	tick int64
 
 	// wheelSize is the bucket count of layer
 	wheelSize int64
 
-	// interval is the count of tick*wheelSize, it's the interval of all this layer can representation
+	// interval is the count of tick*wheelSize, it's the interval of all this layer can representation,
+	// it's math.MaxInt64 if the count overflow
 	interval int64
 
-	// currentTime is the current time in milliseconds
+	// currentTime is the current time in the time unit
 	currentTime int64
 
 	// buckets is the array of bucket, the len is wheelSize
@@ -67,27 +76,29 @@ type wheel struct {
 	overflowWheel *wheel
 }
 
-func (w *wheel) addOrRun(t *timerTask, dq delayqueue.DelayQueue) {
-	if !w.add(t, dq) {
-		now := time.Now()
+func (w *wheel) addOrRun(t *timerTask, dq delayqueue.DelayQueue, e Executor, c clock.Clock) {
+	for !w.add(t, dq) {
+		now := c.Now()
 
-		// the timertask already expired, wo we run execute the timer's task in its own goroutine.
-		defaultExecutor(t.f, now)
+		// the timertask already expired, wo we run execute the timer's task with the executor.
+		e.Execute(t.f, now)
 
-		if t.t == taskTick && t.stopped == 0 {
-			// the timertask is tick func, and haven't been stopped, reinsert it
-			t.expiration = timeToMs(now.Add(t.d))
-			w.addOrRun(t, dq)
+		if !t.reschedule(now, w.currentTime+w.tick) {
+			return
 		}
+		// the timertask is repetitious, and haven't been stopped, reinsert it.
+		// NOTE: the TickFixedRate timertask with MissedTickCatchUp maybe expired many times.
 	}
 }
 
 func (w *wheel) add(t *timerTask, dq delayqueue.DelayQueue) bool {
+	// compare with the offset from currentTime, the currentTime+interval maybe overflow
+	offset := t.expiration - w.currentTime
 	switch {
-	case t.expiration < w.currentTime+w.tick:
+	case offset < w.tick:
 		// if the timertask is in the first bucket, we treat it as expired.
 		return false
-	case t.expiration < w.currentTime+w.interval:
+	case offset < w.interval:
 		// the timertask is in current layer wheel
 
 		// vid is the multiple of expireation and tick,
@@ -127,7 +138,7 @@ func (w *wheel) add(t *timerTask, dq delayqueue.DelayQueue) bool {
 }
 
 func (w *wheel) advanceClock(expiration int64) {
-	if expiration >= w.currentTime+w.tick {
+	if expiration-w.currentTime >= w.tick {
 		w.currentTime = truncate(expiration, w.tick)
 
 		if w.overflowWheel != nil {
@@ -135,3 +146,49 @@ func (w *wheel) advanceClock(expiration int64) {
 		}
 	}
 }
+
+// size returns the count of timertask in the wheel and its overflow wheels
+func (w *wheel) size() int {
+	n := 0
+	for ; w != nil; w = w.overflowWheel {
+		for _, b := range w.buckets {
+			n += b.Len()
+		}
+	}
+	return n
+}
+
+// foreach calls fn with every timertask in the wheel and its overflow wheels,
+// it's safe to remove the timertask from its bucket in fn.
+func (w *wheel) foreach(fn func(*timerTask)) {
+	for ; w != nil; w = w.overflowWheel {
+		for _, b := range w.buckets {
+			e := b.timers.Front()
+			for e != nil {
+				n := e.Next()
+				fn(e.Value.(*timerTask))
+				e = n
+			}
+		}
+	}
+}
+
+// stats returns the snapshot of the wheel and its overflow wheels
+func (w *wheel) stats(unit time.Duration) []LayerStats {
+	layers := make([]LayerStats, 0, 1)
+	for ; w != nil; w = w.overflowWheel {
+		l := LayerStats{
+			Tick:      unitToDuration(w.tick, unit),
+			Interval:  unitToDuration(w.interval, unit),
+			WheelSize: int(w.wheelSize),
+		}
+		for _, b := range w.buckets {
+			if n := b.Len(); n > 0 {
+				l.Occupied++
+				l.Timers += n
+			}
+		}
+		layers = append(layers, l)
+	}
+	return layers
+}
@@ -25,9 +25,11 @@ package timingwheel
 import (
 	"context"
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
+	"github.com/lsytj0413/ena/clock"
 	"github.com/lsytj0413/ena/delayqueue"
 
 	"github.com/stretchr/testify/mock"
@@ -46,9 +48,38 @@ func (m *mockDelayQueue) Size() int {
 	return 0
 }
 
-func (m *mockDelayQueue) Offer(element interface{}, expiration int64) {
+func (m *mockDelayQueue) Offer(element interface{}, expiration int64) delayqueue.Handle {
 	args := m.Called(element, expiration)
 	_ = args
+	return nil
+}
+
+func (m *mockDelayQueue) OfferContext(ctx context.Context, element interface{}, expiration int64) (delayqueue.Handle, error) {
+	return m.Offer(element, expiration), nil
+}
+
+func (m *mockDelayQueue) Peek() delayqueue.Handle {
+	return nil
+}
+
+func (m *mockDelayQueue) Drain() []delayqueue.Handle {
+	return nil
+}
+
+func (m *mockDelayQueue) Take(ctx context.Context) (interface{}, error) {
+	return nil, nil
+}
+
+func (m *mockDelayQueue) TryTake() (interface{}, bool) {
+	return nil, false
+}
+
+func (m *mockDelayQueue) TakeN(ctx context.Context, max int) ([]interface{}, error) {
+	return nil, nil
+}
+
+func (m *mockDelayQueue) Len(s delayqueue.State) int {
+	return 0
 }
 
 func (m *mockDelayQueue) Poll(ctx context.Context) {
@@ -67,7 +98,6 @@ type wheelTestSuite struct {
 func (s *wheelTestSuite) SetupTest() {
 	s.w = newWheel(3, 20, 4)
 	s.dq = &mockDelayQueue{}
-	defaultExecutor = blockExecutor
 
 	s.Equal(int64(3), s.w.tick)
 	s.Equal(int64(20), s.w.wheelSize)
@@ -80,10 +110,6 @@ func (s *wheelTestSuite) SetupTest() {
 	}
 }
 
-func (s *wheelTestSuite) TearDownTest() {
-	defaultExecutor = taskExecutor
-}
-
 func (s *wheelTestSuite) TestNewWheel() {
 	tickMs, wheelSize, startMs := int64(1), int64(20), int64(0)
 	s.w = newWheel(tickMs, wheelSize, startMs)
@@ -222,7 +248,7 @@ func (s *wheelTestSuite) TestAddOrRun() {
 			},
 			t: taskAfter,
 		}
-		s.w.addOrRun(t, s.dq)
+		s.w.addOrRun(t, s.dq, ExecutorFunc(blockExecutor), clock.New())
 		s.Equal(1, v)
 		s.Nil(t.b)
 		s.dq.AssertNotCalled(s.T(), "Offer",
@@ -240,6 +266,7 @@ func (s *wheelTestSuite) TestAddOrRun() {
 		t := &timerTask{
 			expiration: 0,
 			d:          time.Duration(7),
+			u:          time.Millisecond,
 			stopped:    0,
 			f: func(time.Time) {
 				v = 1
@@ -255,7 +282,7 @@ func (s *wheelTestSuite) TestAddOrRun() {
 				return true
 			}),
 		)
-		s.w.addOrRun(t, s.dq)
+		s.w.addOrRun(t, s.dq, ExecutorFunc(blockExecutor), clock.New())
 		s.Equal(1, v)
 		s.NotNil(t.b)
 		s.dq.AssertCalled(s.T(), "Offer",
@@ -288,7 +315,7 @@ func (s *wheelTestSuite) TestAddOrRun() {
 				return true
 			}),
 		)
-		s.w.addOrRun(t, s.dq)
+		s.w.addOrRun(t, s.dq, ExecutorFunc(blockExecutor), clock.New())
 		s.Equal(1, v)
 		s.Nil(t.b)
 		s.dq.AssertNotCalled(s.T(), "Offer",
@@ -313,6 +340,35 @@ func (s *wheelTestSuite) TestAdvanceClock() {
 	s.Equal(exp, s.w.overflowWheel.currentTime)
 }
 
+func (s *wheelTestSuite) TestOverflowInterval() {
+	s.dq.On("Offer", mock.Anything, mock.Anything)
+
+	w := newWheel(math.MaxInt64/10, 20, 0)
+	s.Equal(int64(math.MaxInt64), w.interval)
+
+	// the currentTime+interval is overflow
+	w.currentTime = math.MaxInt64 / 10
+	s.True(w.add(&timerTask{expiration: math.MaxInt64 - 1}, s.dq))
+	s.Nil(w.overflowWheel)
+}
+
+func (s *wheelTestSuite) TestStats() {
+	s.dq.On("Offer", mock.Anything, mock.Anything)
+
+	s.Equal([]LayerStats{
+		{Tick: 3 * time.Millisecond, Interval: 60 * time.Millisecond, WheelSize: 20},
+	}, s.w.stats(time.Millisecond))
+
+	s.True(s.w.add(&timerTask{expiration: 10}, s.dq))
+	s.True(s.w.add(&timerTask{expiration: 11}, s.dq))
+	s.True(s.w.add(&timerTask{expiration: 30}, s.dq))
+	s.True(s.w.add(&timerTask{expiration: 100}, s.dq))
+	s.Equal([]LayerStats{
+		{Tick: 3 * time.Millisecond, Interval: 60 * time.Millisecond, WheelSize: 20, Occupied: 2, Timers: 3},
+		{Tick: 60 * time.Millisecond, Interval: 1200 * time.Millisecond, WheelSize: 20, Occupied: 1, Timers: 1},
+	}, s.w.stats(time.Millisecond))
+}
+
 func TestWheelTestSuite(t *testing.T) {
 	s := &wheelTestSuite{}
 	suite.Run(t, s)